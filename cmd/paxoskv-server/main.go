@@ -0,0 +1,337 @@
+// Command paxoskv-server runs one paxoskv Acceptor.
+//
+// Usage:
+//
+//	paxoskv-server -id 0 -data-dir /var/lib/paxoskv -cluster cluster.conf
+//
+// The Acceptor listens on its address in the cluster file, unless -listen is
+// given, and stores its state in the data dir. On SIGTERM or SIGINT it stops
+// accepting requests, waits for in-flight ones to finish and closes its
+// storage. The standard gRPC health service reports NOT_SERVING while the
+// storage is being recovered and during shutdown.
+//
+// Deleted keys are dropped from the storage by a GC every -gc-interval. Readers
+// write nothing, thus the server also writes, as often and with its own
+// identity, a tombstone for every expired key in the cluster.
+//
+// It also serves the client-facing KVService on the same address, and with
+// -http the HTTP/JSON gateway, both proposing to all Acceptors in the cluster
+// file. The ProposerId is allocated by the cluster when the server starts for
+// the first time, unless -proposer-id is given, and is stored in the data dir
+// along with the highest ballot used.
+//
+// The Admin service, to inspect the Acceptor state remotely, is served on the
+// same address, requiring -admin-token if it is given.
+//
+// Metrics of the Acceptor and the proposer are served on /metrics of the -http
+// address, in the Prometheus text exposition format.
+//
+// With -tls-cert, -tls-key and -tls-ca, the gRPC services are served and other
+// Acceptors are connected with mutual TLS; only peers with a certificate named
+// in the cluster file are accepted. The files are reloaded when they change.
+//
+// With -acl, requests to the Acceptor, KVService and the gateway need the
+// permission on the keys, by the identity of their token or TLS certificate.
+// -token is the identity of this server when proposing to other Acceptors.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/openacid/paxoskv/paxoskv"
+	"google.golang.org/grpc"
+)
+
+func main() {
+
+	id := flag.Int64("id", -1, "id of this Acceptor, required")
+	listen := flag.String("listen", "", "address to listen on; default: the address of this Acceptor in the cluster file, or :<3333+id>")
+	dataDir := flag.String("data-dir", "", "directory to store the Acceptor state in, required")
+	clusterFile := flag.String("cluster", "", "cluster file with the id and address of every Acceptor")
+	shutdownTimeout := flag.Duration("shutdown-timeout", 10*time.Second, "how long to wait for in-flight requests on shutdown")
+	gcInterval := flag.Duration("gc-interval", time.Minute, "how often to write tombstones for expired keys and to look for deleted keys to drop from the storage; a key is dropped after two intervals; no GC if 0")
+	httpAddr := flag.String("http", "", "address to serve the HTTP/JSON gateway and /metrics on; disabled if empty")
+	proposerId := flag.Int64("proposer-id", 0, "ProposerId of KVService and the gateway, must be unique among proposers; default: allocated by the cluster")
+	adminToken := flag.String("admin-token", "", "token required by the Admin service; no auth if empty")
+	logLevel := flag.String("log-level", "info", "log level of the Acceptor and the proposer: debug, info, warn, error or none")
+	traceFile := flag.String("trace-file", "", "file to append trace spans to, as JSON lines")
+	traceCollector := flag.String("trace-collector", "", "URL of a collector to post trace spans to, if -trace-file is not given")
+	tlsCert := flag.String("tls-cert", "", "certificate of this node, to serve and to connect to other Acceptors with mutual TLS; plaintext if empty")
+	tlsKey := flag.String("tls-key", "", "key of -tls-cert")
+	tlsCA := flag.String("tls-ca", "", "CA to verify the certificates of peers with")
+	aclFile := flag.String("acl", "", "ACL file with the tokens and the permissions on key prefixes of identities; no access control if empty")
+	token := flag.String("token", "", "token to send to other Acceptors, as the identity of the proposer of this server in their ACL")
+	flag.Parse()
+
+	if *id < 0 || *dataDir == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	level, err := paxoskv.ParseLevel(*logLevel)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	paxoskv.SetLogger(paxoskv.NewTextLogger(os.Stderr, level))
+
+	closeTracer, err := setTracer(*traceFile, *traceCollector)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer closeTracer()
+
+	cfg := &config{
+		id:              *id,
+		listen:          *listen,
+		dataDir:         *dataDir,
+		clusterFile:     *clusterFile,
+		shutdownTimeout: *shutdownTimeout,
+		gcInterval:      *gcInterval,
+		httpAddr:        *httpAddr,
+		proposerId:      *proposerId,
+		adminToken:      *adminToken,
+		tlsCert:         *tlsCert,
+		tlsKey:          *tlsKey,
+		tlsCA:           *tlsCA,
+		aclFile:         *aclFile,
+		token:           *token,
+	}
+	if err := run(cfg); err != nil {
+		closeTracer()
+		log.Fatalf("paxoskv-server: %v", err)
+	}
+}
+
+// setTracer sets the Tracer exporting to a file, or to a collector, if either
+// is given. The returned func flushes and closes the exporter.
+func setTracer(file, collector string) (func(), error) {
+	switch {
+	case file != "":
+		f, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("open trace file: %w", err)
+		}
+		paxoskv.SetTracer(paxoskv.NewTracer(paxoskv.NewJSONExporter(f)))
+		return func() { f.Close() }, nil
+
+	case collector != "":
+		e := paxoskv.NewCollectorExporter(collector)
+		paxoskv.SetTracer(paxoskv.NewTracer(e))
+		return e.Close, nil
+
+	default:
+		return func() {}, nil
+	}
+}
+
+type config struct {
+	id              int64
+	listen          string
+	dataDir         string
+	clusterFile     string
+	shutdownTimeout time.Duration
+	gcInterval      time.Duration
+	httpAddr        string
+	proposerId      int64
+	adminToken      string
+	tlsCert         string
+	tlsKey          string
+	tlsCA           string
+	aclFile         string
+	token           string
+}
+
+func run(cfg *config) error {
+
+	id, listen, dataDir, clusterFile := cfg.id, cfg.listen, cfg.dataDir, cfg.clusterFile
+	acceptorIds := []int64{0, 1, 2}
+
+	if clusterFile != "" {
+		cluster, err := paxoskv.LoadCluster(clusterFile)
+		if err != nil {
+			return fmt.Errorf("load cluster file: %w", err)
+		}
+		if _, found := cluster.Addrs[id]; !found {
+			return fmt.Errorf("Acceptor-%d is not in cluster file: %s", id, clusterFile)
+		}
+		paxoskv.UseCluster(cluster)
+		acceptorIds = cluster.AcceptorIds()
+
+		if listen == "" {
+			listen = cluster.Addrs[id]
+		}
+	}
+	if listen == "" {
+		listen = fmt.Sprintf(":%d", paxoskv.AcceptorBasePort+id)
+	}
+
+	var opts []grpc.ServerOption
+	if cfg.tlsCert != "" {
+		t, err := paxoskv.LoadTLS(cfg.tlsCert, cfg.tlsKey, cfg.tlsCA)
+		if err != nil {
+			return fmt.Errorf("load TLS: %w", err)
+		}
+		paxoskv.UseTLS(t)
+		opts = append(opts, t.ServerOption())
+	}
+
+	var acl *paxoskv.ACL
+	if cfg.aclFile != "" {
+		var err error
+		acl, err = paxoskv.LoadACL(cfg.aclFile)
+		if err != nil {
+			return fmt.Errorf("load ACL file: %w", err)
+		}
+	}
+	paxoskv.UseToken(cfg.token)
+
+	kvs, err := paxoskv.NewKVServer("")
+	if err != nil {
+		return err
+	}
+	kvs.Id = id
+	kvs.ACL = acl
+
+	svc := paxoskv.NewKVService(nil)
+	svc.ACL = acl
+
+	// The health service reports NOT_SERVING and other requests are refused
+	// until the storage is recovered.
+	a := paxoskv.NewAcceptorServer(kvs, listen, opts...)
+	a.Dir = dataDir
+	a.GCInterval = cfg.gcInterval
+	paxoskv.RegisterKVServiceServer(a.Server, svc)
+	paxoskv.RegisterAdminServer(a.Server, paxoskv.NewAdminService(kvs, cfg.adminToken))
+
+	if err := a.Start(); err != nil {
+		return err
+	}
+	// Closes the storage on any failure; does nothing after shutdown.
+	defer a.Stop()
+	log.Printf("Acceptor-%d serving on %s, data dir: %s", id, a.Addr(), dataDir)
+
+	// Allocating a ProposerId needs a quorum of Acceptors, which may be
+	// starting too. Thus it is done after this Acceptor starts serving.
+	c := &paxoskv.KVClient{
+		AcceptorIds: acceptorIds,
+		ProposerId:  cfg.proposerId,
+		StateFile:   filepath.Join(dataDir, "proposer.json"),
+	}
+	ready := make(chan error, 1)
+	go func() {
+		ready <- c.LoadState()
+	}()
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGTERM, syscall.SIGINT)
+
+	var hs *http.Server
+	httpErr := make(chan error, 1)
+
+	for ready != nil {
+		select {
+		case <-a.Done():
+			return a.Err()
+		case got := <-sig:
+			log.Printf("Acceptor-%d: recv %v, shutting down", id, got)
+			return shutdown(cfg, a, nil)
+		case err := <-ready:
+			if err != nil {
+				return fmt.Errorf("load proposer state: %w", err)
+			}
+			ready = nil
+		}
+	}
+
+	svc.SetClient(c)
+	log.Printf("Acceptor-%d: proposing with ProposerId %d", id, c.ProposerId)
+
+	if cfg.gcInterval > 0 {
+		go expire(id, c, cfg.gcInterval)
+	}
+
+	if cfg.httpAddr != "" {
+		mux := http.NewServeMux()
+		g := paxoskv.NewGateway(c)
+		g.ACL = acl
+		mux.Handle("/", g)
+		mux.Handle("/metrics", paxoskv.NewMetricsHandler(kvs))
+		hs = &http.Server{Addr: cfg.httpAddr, Handler: mux}
+		go func() {
+			if err := hs.ListenAndServe(); err != http.ErrServerClosed {
+				httpErr <- err
+			}
+		}()
+		log.Printf("Acceptor-%d serving HTTP gateway on %s", id, cfg.httpAddr)
+	}
+
+	select {
+	case <-a.Done():
+		return a.Err()
+	case err := <-httpErr:
+		return err
+	case got := <-sig:
+		log.Printf("Acceptor-%d: recv %v, shutting down", id, got)
+	}
+
+	return shutdown(cfg, a, hs)
+}
+
+// expire writes a tombstone for every expired key, every `interval`.
+func expire(id int64, c *paxoskv.KVClient, interval time.Duration) {
+	for range time.Tick(interval) {
+		n, err := c.Expire("", "")
+		if err != nil {
+			log.Printf("Acceptor-%d: expire: %v", id, err)
+			continue
+		}
+		if n > 0 {
+			log.Printf("Acceptor-%d: wrote tombstones for %d expired keys", id, n)
+		}
+	}
+}
+
+// shutdown stops the HTTP gateway if there is one, then the Acceptor, waiting
+// for in-flight requests no longer than the shutdown timeout, and flushes its
+// storage.
+func shutdown(cfg *config, a *paxoskv.AcceptorServer, hs *http.Server) error {
+
+	id := cfg.id
+
+	// Proposers skip this Acceptor from now on, and new requests are refused.
+	a.Health.SetServing(false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.shutdownTimeout)
+	defer cancel()
+
+	if hs != nil {
+		if err := hs.Shutdown(ctx); err != nil {
+			log.Printf("Acceptor-%d: HTTP gateway shutdown: %v", id, err)
+		}
+	}
+
+	// A Watch stream never ends by itself, stop forcibly after a while.
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- a.GracefulStop()
+	}()
+
+	select {
+	case err := <-stopped:
+		return err
+	case <-ctx.Done():
+		log.Printf("Acceptor-%d: shutdown timeout, stop forcibly", id)
+		return a.Stop()
+	}
+}
@@ -0,0 +1,274 @@
+// Command paxoskv is a command-line client of paxoskv.
+//
+// Usage:
+//
+//	paxoskv [flags] set <key> <int64>
+//	paxoskv [flags] get <key>[@<ver>]
+//	paxoskv [flags] delete <key>
+//	paxoskv [flags] history <key>
+//	paxoskv [flags] inspect <key>@<ver>
+//
+// `get key@ver` reads the value chosen for a version. `inspect` prints the raw
+// state of an instance on every Acceptor, without running paxos, to debug an
+// instance that does not make progress.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/openacid/paxoskv/paxoskv"
+)
+
+func main() {
+
+	clusterFile := flag.String("cluster", "", "cluster file with the id and address of every Acceptor")
+	acceptors := flag.String("acceptors", "", "comma separated ids of the Acceptors to use; default: all in the cluster file, or 0,1,2")
+	proposerId := flag.Int64("proposer-id", 0, "ProposerId in ballot numbers, must be unique among proposers; default: allocated by the cluster for set and delete, random for the others")
+	verbose := flag.Bool("v", false, "log every paxos round to stderr")
+	tlsCert := flag.String("tls-cert", "", "certificate to connect to Acceptors with mutual TLS, named by a peer line in the cluster file; plaintext if empty")
+	tlsKey := flag.String("tls-key", "", "key of -tls-cert")
+	tlsCA := flag.String("tls-ca", "", "CA to verify the certificates of Acceptors with")
+	token := flag.String("token", "", "token to send to Acceptors, as the identity in their ACL")
+
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), `Usage:
+  paxoskv [flags] set <key> <int64>
+  paxoskv [flags] get <key>[@<ver>]
+  paxoskv [flags] delete <key>
+  paxoskv [flags] history <key>
+  paxoskv [flags] inspect <key>@<ver>
+
+Flags:
+`)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *verbose {
+		paxoskv.SetLogger(paxoskv.NewTextLogger(os.Stderr, paxoskv.LevelDebug))
+	}
+
+	if *tlsCert != "" {
+		t, err := paxoskv.LoadTLS(*tlsCert, *tlsKey, *tlsCA)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		paxoskv.UseTLS(t)
+	}
+
+	paxoskv.UseToken(*token)
+
+	c, err := newClient(*clusterFile, *acceptors, *proposerId, readOnly(flag.Args()))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	err = run(c, flag.Args())
+	if errors.Is(err, errUsage) {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+var errUsage = errors.New("usage")
+
+// readOnly returns whether a command writes nothing, thus does not need an
+// allocated ProposerId.
+func readOnly(args []string) bool {
+	if len(args) == 0 {
+		return true
+	}
+	switch args[0] {
+	case "get", "history", "inspect":
+		return true
+	}
+	return false
+}
+
+// newClient creates a KVClient. Without `proposerId`, a ProposerId is allocated
+// by the cluster, or is a random one if `readOnly`: allocating needs a quorum,
+// which inspect must not wait for.
+func newClient(clusterFile, acceptors string, proposerId int64, readOnly bool) (*paxoskv.KVClient, error) {
+
+	ids := []int64{0, 1, 2}
+
+	if clusterFile != "" {
+		cluster, err := paxoskv.LoadCluster(clusterFile)
+		if err != nil {
+			return nil, fmt.Errorf("load cluster file: %w", err)
+		}
+		paxoskv.UseCluster(cluster)
+		ids = cluster.AcceptorIds()
+	}
+
+	if acceptors != "" {
+		ids = []int64{}
+		for _, s := range strings.Split(acceptors, ",") {
+			id, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid acceptor id: %q", s)
+			}
+			ids = append(ids, id)
+		}
+	}
+
+	c := &paxoskv.KVClient{AcceptorIds: ids, ProposerId: proposerId}
+	if proposerId == 0 && readOnly {
+		id, err := paxoskv.RandomProposerId()
+		if err != nil {
+			return nil, err
+		}
+		c.ProposerId = id
+	} else if proposerId == 0 {
+		id, err := c.AllocProposerId()
+		if err != nil {
+			return nil, fmt.Errorf("allocate ProposerId: %w", err)
+		}
+		c.ProposerId = id
+	}
+	return c, nil
+}
+
+func run(c *paxoskv.KVClient, args []string) error {
+
+	if len(args) != 2 && !(len(args) == 3 && args[0] == "set") {
+		return errUsage
+	}
+
+	cmd, key := args[0], args[1]
+
+	switch cmd {
+	case "set":
+		n, err := strconv.ParseInt(args[2], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid value: %q", args[2])
+		}
+		ver, err := c.Set(key, &paxoskv.Value{Vi64: n})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s@%d %d\n", key, ver, n)
+
+	case "get":
+		key, ver, err := parseKeyVer(key, false)
+		if err != nil {
+			return err
+		}
+		if ver < 0 {
+			v, ver, err := c.Get(key)
+			if err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			fmt.Printf("%s@%d %d\n", key, ver, v.Vi64)
+			return nil
+		}
+
+		records, err := c.History(key, ver, ver)
+		if err != nil {
+			return err
+		}
+		if len(records) == 0 {
+			return fmt.Errorf("%s@%d: %w", key, ver, paxoskv.NotFound)
+		}
+		printRecord(records[0])
+
+	case "delete":
+		ver, err := c.Delete(key)
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		fmt.Printf("%s@%d deleted\n", key, ver)
+
+	case "history":
+		records, err := c.History(key, 0, -1)
+		if err != nil {
+			return err
+		}
+		for _, r := range records {
+			printRecord(r)
+		}
+
+	case "inspect":
+		key, ver, err := parseKeyVer(key, true)
+		if err != nil {
+			return err
+		}
+		states := c.Inspect(&paxoskv.PaxosInstanceId{Key: key, Ver: ver})
+
+		aids := make([]int64, 0, len(states))
+		for aid := range states {
+			aids = append(aids, aid)
+		}
+		sort.Slice(aids, func(i, j int) bool { return aids[i] < aids[j] })
+
+		for _, aid := range aids {
+			a := states[aid]
+			if a == nil {
+				fmt.Printf("Acceptor-%d: absent\n", aid)
+				continue
+			}
+			if a.Status == paxoskv.AcceptorStatus_InstanceCompacted {
+				fmt.Printf("Acceptor-%d: compacted\n", aid)
+				continue
+			}
+			fmt.Printf("Acceptor-%d: LastBal: %s VBal: %s Val: %s Committed: %v\n",
+				aid, fmtBal(a.LastBal), fmtBal(a.VBal), fmtVal(a.Val), a.Committed)
+		}
+
+	default:
+		return errUsage
+	}
+
+	return nil
+}
+
+// parseKeyVer parses "key@ver". The version is -1 if absent and not required.
+func parseKeyVer(s string, required bool) (string, int64, error) {
+	i := strings.LastIndex(s, "@")
+	if i < 0 {
+		if required {
+			return "", 0, fmt.Errorf("expect <key>@<ver>: %q", s)
+		}
+		return s, -1, nil
+	}
+
+	ver, err := strconv.ParseInt(s[i+1:], 10, 64)
+	if err != nil || ver < 0 {
+		return "", 0, fmt.Errorf("invalid version: %q", s)
+	}
+	return s[:i], ver, nil
+}
+
+func printRecord(r *paxoskv.Record) {
+	fmt.Printf("%s@%d %s bal: %s\n", r.Key, r.Ver, fmtVal(r.Val), fmtBal(r.Bal))
+}
+
+func fmtBal(b *paxoskv.BallotNum) string {
+	if b == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%d,%d", b.N, b.ProposerId)
+}
+
+func fmtVal(v *paxoskv.Value) string {
+	switch {
+	case v == nil:
+		return "-"
+	case v.Deleted:
+		return "<deleted>"
+	default:
+		return strconv.FormatInt(v.Vi64, 10)
+	}
+}
@@ -1,10 +1,9 @@
 module github.com/openacid/paxoskv
 
-go 1.17
+go 1.18
 
 require (
 	github.com/golang/protobuf v1.5.2
-	github.com/kr/pretty v0.3.1
 	github.com/stretchr/testify v1.8.1
 	golang.org/x/net v0.0.0-20201031054903-ff519b6c9102
 	google.golang.org/grpc v1.50.1
@@ -13,9 +12,7 @@ require (
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
-	github.com/kr/text v0.2.0 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	github.com/rogpeppe/go-internal v1.9.0 // indirect
 	golang.org/x/sys v0.0.0-20210119212857-b64e53b001e4 // indirect
 	golang.org/x/text v0.3.3 // indirect
 	google.golang.org/genproto v0.0.0-20200526211855-cb27e3aa2013 // indirect
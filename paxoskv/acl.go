@@ -0,0 +1,310 @@
+package paxoskv
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Perm is a set of permissions on keys.
+type Perm int
+
+const (
+	PermRead Perm = 1 << iota
+	PermWrite
+
+	PermReadWrite = PermRead | PermWrite
+)
+
+// ACL grants permissions on keys by key prefix to identities.
+//
+// The identity of a request is the one of its token, see WithToken, or a name
+// in its TLS client certificate, i.e., the common name or a DNS name.
+//
+// An ACL file has one token or one rule per line, e.g.:
+//
+//	# token <token> <identity>
+//	token s3cr3t alice
+//	# allow <identity> <r|w|rw> <key prefix>
+//	allow alice rw user/alice/
+//	allow alice r  user/
+//	allow acceptor-0.paxoskv rw *
+//
+// Prefix "*" is all keys, including the internal ones, which the proposers of
+// Acceptors must be able to write. Identity "*" is any identity.
+// Empty lines and lines starting with "#" are ignored.
+type ACL struct {
+	// Tokens maps a token to the identity it authenticates.
+	Tokens map[string]string
+
+	Rules []ACLRule
+}
+
+// ACLRule grants Perm on keys starting with Prefix to Identity.
+type ACLRule struct {
+	Identity string
+	Prefix   string
+	Perm     Perm
+}
+
+// LoadACL reads an ACL file.
+func LoadACL(path string) (*ACL, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ParseACL(f)
+}
+
+// ParseACL reads an ACL in the format of an ACL file.
+func ParseACL(r io.Reader) (*ACL, error) {
+
+	a := &ACL{Tokens: map[string]string{}}
+
+	scanner := bufio.NewScanner(r)
+	lineno := 0
+	for scanner.Scan() {
+		lineno++
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		switch {
+		case fields[0] == "token" && len(fields) == 3:
+			if _, found := a.Tokens[fields[1]]; found {
+				return nil, fmt.Errorf("line %d: duplicate token", lineno)
+			}
+			a.Tokens[fields[1]] = fields[2]
+
+		case fields[0] == "allow" && len(fields) == 4:
+			perm, found := map[string]Perm{"r": PermRead, "w": PermWrite, "rw": PermReadWrite}[fields[2]]
+			if !found {
+				return nil, fmt.Errorf("line %d: invalid permission: %q", lineno, fields[2])
+			}
+			prefix := fields[3]
+			if prefix == "*" {
+				prefix = ""
+			}
+			a.Rules = append(a.Rules, ACLRule{Identity: fields[1], Prefix: prefix, Perm: perm})
+
+		default:
+			return nil, fmt.Errorf("line %d: expect token <token> <identity> or allow <identity> <perm> <prefix>: %q", lineno, line)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// allowed returns whether any of `ids` has `perm` on all keys starting with
+// `prefix`, e.g., on a key, or on the keys a Watch request watches.
+func (a *ACL) allowed(ids []string, prefix string, perm Perm) bool {
+	for _, rule := range a.Rules {
+		if rule.Perm&perm != perm || !strings.HasPrefix(prefix, rule.Prefix) {
+			continue
+		}
+		for _, id := range ids {
+			if rule.Identity == "*" || rule.Identity == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// identify returns the identities of a request with `tokens` and TLS client
+// certificate `cert`. It returns an error with code Unauthenticated if a
+// token is unknown.
+func (a *ACL) identify(tokens []string, cert *x509.Certificate) ([]string, error) {
+
+	ids := []string{}
+
+	for _, token := range tokens {
+		id, found := "", false
+		for t, tid := range a.Tokens {
+			if subtle.ConstantTimeCompare([]byte(token), []byte(t)) == 1 {
+				id, found = tid, true
+			}
+		}
+		if !found {
+			return nil, status.Error(codes.Unauthenticated, "invalid token")
+		}
+		ids = append(ids, id)
+	}
+
+	if cert != nil {
+		ids = append(ids, cert.Subject.CommonName)
+		ids = append(ids, cert.DNSNames...)
+	}
+	return ids, nil
+}
+
+// identities returns the identities of a gRPC request.
+func (a *ACL) identities(ctx context.Context) ([]string, error) {
+	return a.identify(credentialsOf(ctx))
+}
+
+// credentialsOf returns the tokens and the TLS client certificate of a gRPC
+// request.
+func credentialsOf(ctx context.Context) ([]string, *x509.Certificate) {
+
+	tokens := []string{}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, v := range md.Get(authorizationKey) {
+		tokens = append(tokens, strings.TrimPrefix(v, "Bearer "))
+	}
+
+	var cert *x509.Certificate
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(info.State.PeerCertificates) > 0 {
+			cert = info.State.PeerCertificates[0]
+		}
+	}
+
+	return tokens, cert
+}
+
+// check returns an error with code PermissionDenied if the identity of a gRPC
+// request does not have `perm` on all keys starting with `prefix`.
+// A nil ACL allows everything.
+func (a *ACL) check(ctx context.Context, prefix string, perm Perm) error {
+	if a == nil {
+		return nil
+	}
+
+	ids, err := a.identities(ctx)
+	if err != nil {
+		return err
+	}
+	if !a.allowed(ids, prefix, perm) {
+		return status.Errorf(codes.PermissionDenied, "%v has no %s permission on %q", ids, perm, prefix)
+	}
+	return nil
+}
+
+// filter returns the keys the identity of a gRPC request may read.
+func (a *ACL) filter(ctx context.Context, keys []string) ([]string, error) {
+	if a == nil {
+		return keys, nil
+	}
+
+	ids, err := a.identities(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	readable := []string{}
+	for _, key := range keys {
+		if a.allowed(ids, key, PermRead) {
+			readable = append(readable, key)
+		}
+	}
+	return readable, nil
+}
+
+// checkHTTP is check for an HTTP request, with the token in the header
+// "Authorization: Bearer <token>".
+func (a *ACL) checkHTTP(r *http.Request, prefix string, perm Perm) error {
+	if a == nil {
+		return nil
+	}
+
+	tokens := []string{}
+	for _, v := range r.Header.Values("Authorization") {
+		tokens = append(tokens, strings.TrimPrefix(v, "Bearer "))
+	}
+
+	var cert *x509.Certificate
+	if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+		cert = r.TLS.PeerCertificates[0]
+	}
+
+	ids, err := a.identify(tokens, cert)
+	if err != nil {
+		return err
+	}
+	if !a.allowed(ids, prefix, perm) {
+		return status.Errorf(codes.PermissionDenied, "%v has no %s permission on %q", ids, perm, prefix)
+	}
+	return nil
+}
+
+func (p Perm) String() string {
+	switch p {
+	case PermRead:
+		return "read"
+	case PermWrite:
+		return "write"
+	default:
+		return "read-write"
+	}
+}
+
+// WithToken returns a context to call the KV API, or the API of an Acceptor,
+// as the identity of `token` in the ACL.
+func WithToken(ctx context.Context, token string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, authorizationKey, "Bearer "+token)
+}
+
+// tokenCreds attaches a token to every RPC.
+type tokenCreds string
+
+func (t tokenCreds) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{authorizationKey: "Bearer " + string(t)}, nil
+}
+
+func (t tokenCreds) RequireTransportSecurity() bool {
+	return false
+}
+
+var (
+	// tokenMu protects defaultToken.
+	tokenMu sync.RWMutex
+
+	// defaultToken is the token set by UseToken.
+	defaultToken string
+)
+
+// UseToken makes Proposers and clients send `token` in every request to
+// Acceptors, as their identity in the ACL of the Acceptors. An empty `token`
+// sends none. Without TLS the token is sent in plaintext.
+//
+// Connections are reused, thus it should be called before connecting to any
+// Acceptor.
+func UseToken(token string) {
+	tokenMu.Lock()
+	defer tokenMu.Unlock()
+	defaultToken = token
+}
+
+// tokenDialOptions returns the options to send the token set by UseToken.
+func tokenDialOptions() []grpc.DialOption {
+	tokenMu.RLock()
+	defer tokenMu.RUnlock()
+
+	if defaultToken == "" {
+		return nil
+	}
+	return []grpc.DialOption{grpc.WithPerRPCCredentials(tokenCreds(defaultToken))}
+}
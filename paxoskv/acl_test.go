@@ -0,0 +1,180 @@
+package paxoskv
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const testACL = `
+# proposers of the cluster
+token p-token proposer
+allow proposer rw *
+
+token a-token alice
+token b-token bob
+allow alice rw user/alice/
+allow alice r  user/
+allow bob   rw user/bob/
+`
+
+func TestParseACL(t *testing.T) {
+
+	ta := require.New(t)
+
+	acl, err := ParseACL(strings.NewReader(testACL))
+	ta.Nil(err)
+	ta.Equal(map[string]string{"p-token": "proposer", "a-token": "alice", "b-token": "bob"}, acl.Tokens)
+	ta.Equal(ACLRule{Identity: "proposer", Prefix: "", Perm: PermReadWrite}, acl.Rules[0])
+	ta.Equal(ACLRule{Identity: "alice", Prefix: "user/", Perm: PermRead}, acl.Rules[2])
+
+	ta.True(acl.allowed([]string{"alice"}, "user/alice/x", PermWrite))
+	ta.True(acl.allowed([]string{"alice"}, "user/bob/x", PermRead))
+	ta.False(acl.allowed([]string{"alice"}, "user/bob/x", PermWrite))
+	ta.False(acl.allowed([]string{"alice"}, "user", PermRead))
+	ta.True(acl.allowed([]string{"x", "bob"}, "user/bob/", PermReadWrite))
+	ta.False(acl.allowed(nil, "user/bob/", PermRead))
+
+	for _, bad := range []string{
+		"token a",
+		"allow alice x user/",
+		"allow alice r",
+		"deny alice r user/",
+		"token a x\ntoken a y",
+	} {
+		_, err := ParseACL(strings.NewReader(bad))
+		ta.NotNil(err, bad)
+	}
+}
+
+func TestACL(t *testing.T) {
+
+	ta := require.New(t)
+
+	acl, err := ParseACL(strings.NewReader(testACL))
+	ta.Nil(err)
+
+	// Acceptors on new addresses, thus connected with the token.
+	UseToken("p-token")
+	defer UseToken("")
+
+	cluster := &Cluster{Addrs: map[int64]string{}}
+	for _, aid := range []int64{0, 1, 2} {
+		kvs, err := NewKVServer("")
+		ta.Nil(err)
+		kvs.ACL = acl
+
+		a := NewAcceptorServer(kvs, "127.0.0.1:0")
+		ta.Nil(a.Start())
+		defer a.Stop()
+		cluster.Addrs[aid] = a.Addr()
+	}
+	UseCluster(cluster)
+	defer UseCluster(nil)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	ta.Nil(err)
+
+	c := &KVClient{AcceptorIds: []int64{0, 1, 2}, ProposerId: 2}
+	svc := NewKVService(c)
+	svc.ACL = acl
+
+	gs := grpc.NewServer()
+	RegisterKVServiceServer(gs, svc)
+	go gs.Serve(lis)
+	defer gs.Stop()
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	ta.Nil(err)
+	defer conn.Close()
+
+	cli := NewKVServiceClient(conn)
+	alice := WithToken(context.Background(), "a-token")
+	bob := WithToken(context.Background(), "b-token")
+
+	_, err = cli.Put(alice, &Record{Key: "user/alice/x", Val: &Value{Vi64: 1}})
+	ta.Nil(err)
+	_, err = cli.Put(bob, &Record{Key: "user/bob/x", Val: &Value{Vi64: 2}})
+	ta.Nil(err)
+
+	_, err = cli.Put(alice, &Record{Key: "user/bob/x", Val: &Value{Vi64: 3}})
+	ta.Equal(codes.PermissionDenied, status.Code(err))
+	_, err = cli.Delete(alice, &Record{Key: "user/bob/x"})
+	ta.Equal(codes.PermissionDenied, status.Code(err))
+
+	rec, err := cli.Get(alice, &Record{Key: "user/bob/x"})
+	ta.Nil(err)
+	ta.Equal(int64(2), rec.Val.Vi64)
+
+	_, err = cli.Get(bob, &Record{Key: "user/alice/x"})
+	ta.Equal(codes.PermissionDenied, status.Code(err))
+
+	_, err = cli.Get(context.Background(), &Record{Key: "user/bob/x"})
+	ta.Equal(codes.PermissionDenied, status.Code(err), "no identity")
+
+	_, err = cli.Get(WithToken(context.Background(), "x"), &Record{Key: "user/bob/x"})
+	ta.Equal(codes.Unauthenticated, status.Code(err))
+
+	list, err := cli.Scan(bob, &KeyRange{Start: "user/"})
+	ta.Nil(err)
+	ta.Equal(1, len(list.Records))
+	ta.Equal("user/bob/x", list.Records[0].Key)
+
+	list, err = cli.Scan(alice, &KeyRange{Start: "user/", Limit: 1})
+	ta.Nil(err)
+	ta.Equal(1, len(list.Records))
+	ta.Equal("user/alice/x", list.Records[0].Key)
+
+	// the Acceptors refuse clients other than the proposers.
+	aconn, err := grpc.Dial(cluster.Addrs[0], grpc.WithInsecure())
+	ta.Nil(err)
+	defer aconn.Close()
+
+	acli := NewPaxosKVClient(aconn)
+	p := &Proposer{Id: &PaxosInstanceId{Key: "user/bob/x", Ver: 0}, Bal: &BallotNum{N: 100, ProposerId: 1}, Val: &Value{Vi64: 1}}
+
+	_, err = acli.Prepare(alice, p)
+	ta.Equal(codes.PermissionDenied, status.Code(err))
+	_, err = acli.Commit(context.Background(), p)
+	ta.Equal(codes.PermissionDenied, status.Code(err))
+
+	_, err = acli.Inspect(alice, p.Id)
+	ta.Nil(err)
+
+	keys, err := acli.Keys(bob, &KeyRange{})
+	ta.Nil(err)
+	ta.Equal([]string{"user/bob/x"}, keys.Keys)
+
+	// the HTTP gateway
+	g := NewGateway(c)
+	g.ACL = acl
+
+	for _, tc := range []struct {
+		method string
+		token  string
+		key    string
+		code   int
+	}{
+		{"GET", "a-token", "user/bob/x", http.StatusOK},
+		{"PUT", "a-token", "user/bob/x", http.StatusForbidden},
+		{"DELETE", "b-token", "user/bob/x", http.StatusOK},
+		{"GET", "", "user/bob/x", http.StatusForbidden},
+		{"GET", "x", "user/bob/x", http.StatusUnauthorized},
+	} {
+		r := httptest.NewRequest(tc.method, gatewayPrefix+tc.key, strings.NewReader(`{"value": 4}`))
+		if tc.token != "" {
+			r.Header.Set("Authorization", "Bearer "+tc.token)
+		}
+		w := httptest.NewRecorder()
+		g.ServeHTTP(w, r)
+		ta.Equal(tc.code, w.Code, "%+v", tc)
+	}
+}
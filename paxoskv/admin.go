@@ -0,0 +1,155 @@
+package paxoskv
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// authorizationKey is the gRPC metadata key carrying the admin token, as
+// "Bearer <token>".
+const authorizationKey = "authorization"
+
+// AdminService implements the Admin API on a KVServer.
+// It is authorized by Token. With the ACL of the KVServer, a request also needs
+// the permission to read the keys it lists or inspects, as Keys and Inspect do.
+type AdminService struct {
+	UnimplementedAdminServer
+
+	kvs *KVServer
+
+	// Token, if not empty, is required in every request. See WithAdminToken.
+	Token string
+}
+
+// NewAdminService creates an AdminService of `kvs`, requiring `token` in every
+// request if it is not empty.
+func NewAdminService(kvs *KVServer, token string) *AdminService {
+	return &AdminService{kvs: kvs, Token: token}
+}
+
+// WithAdminToken returns a context to call the Admin API of an Acceptor
+// requiring `token`.
+func WithAdminToken(ctx context.Context, token string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, authorizationKey, "Bearer "+token)
+}
+
+// authorize returns an error with code Unauthenticated if the request does not
+// carry the token.
+func (a *AdminService) authorize(ctx context.Context) error {
+	if a.Token == "" {
+		return nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, v := range md.Get(authorizationKey) {
+		got := strings.TrimPrefix(v, "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(got), []byte(a.Token)) == 1 {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "invalid admin token")
+}
+
+// identities returns the identities of a request in the ACL of the KVServer.
+// The admin token is not in the ACL thus it is ignored.
+func (a *AdminService) identities(ctx context.Context) ([]string, error) {
+	tokens, cert := credentialsOf(ctx)
+
+	others := []string{}
+	for _, token := range tokens {
+		if a.Token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(a.Token)) != 1 {
+			others = append(others, token)
+		}
+	}
+	return a.kvs.ACL.identify(others, cert)
+}
+
+// ListKeys handles ListKeys request.
+// With an ACL, only the keys the request may read are listed.
+func (a *AdminService) ListKeys(ctx context.Context, r *KeyRange) (*KeyList, error) {
+	if err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	if a.kvs.ACL == nil {
+		return a.kvs.keysIn(r), nil
+	}
+
+	ids, err := a.identities(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// r.Limit is applied to the readable keys.
+	keys := []string{}
+	for _, key := range a.kvs.keysIn(&KeyRange{Start: r.Start, End: r.End}).Keys {
+		if r.Limit > 0 && int64(len(keys)) == r.Limit {
+			break
+		}
+		if a.kvs.ACL.allowed(ids, key, PermRead) {
+			keys = append(keys, key)
+		}
+	}
+	return &KeyList{Keys: keys}, nil
+}
+
+// GetInstance handles GetInstance request.
+// With an ACL, it needs the permission to read the key.
+func (a *AdminService) GetInstance(ctx context.Context, id *PaxosInstanceId) (*Acceptor, error) {
+	if err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+	if err := checkInstanceId(id); err != nil {
+		return nil, err
+	}
+
+	if a.kvs.ACL != nil {
+		ids, err := a.identities(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if !a.kvs.ACL.allowed(ids, id.Key, PermRead) {
+			return nil, status.Errorf(codes.PermissionDenied, "%v has no %s permission on %q", ids, PermRead, id.Key)
+		}
+	}
+	return a.kvs.inspect(id)
+}
+
+// Stats handles Stats request.
+func (a *AdminService) Stats(ctx context.Context, r *StatsRequest) (*AcceptorStats, error) {
+	if err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+	return a.kvs.stats(), nil
+}
+
+// stats returns the numbers of keys, instances and so on in this Acceptor.
+func (s *KVServer) stats() *AcceptorStats {
+	st := &AcceptorStats{Id: s.Id}
+
+	s.mu.Lock()
+	st.Keys = int64(len(s.Storage))
+	st.Fences = int64(len(s.fences))
+	for _, rec := range s.Storage {
+		for _, v := range rec {
+			st.Instances++
+			v.mu.Lock()
+			if v.acceptor.Committed {
+				st.Committed++
+			}
+			v.mu.Unlock()
+		}
+	}
+	s.mu.Unlock()
+
+	s.watchMu.Lock()
+	st.Watchers = int64(len(s.watchers))
+	s.watchMu.Unlock()
+
+	return st
+}
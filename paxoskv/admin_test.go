@@ -0,0 +1,149 @@
+package paxoskv
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestAdminService(t *testing.T) {
+
+	ta := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+
+	servers, err := ServeAcceptors(acceptorIds)
+	ta.Nil(err)
+	defer func() {
+		for _, s := range servers {
+			s.Stop()
+		}
+	}()
+
+	c := &KVClient{AcceptorIds: acceptorIds, ProposerId: 2}
+	for _, k := range []string{"foo", "bar", "foo"} {
+		_, err := c.Set(k, &Value{Vi64: 1})
+		ta.Nil(err)
+	}
+
+	conn, err := grpc.Dial(acceptorAddr(0), grpc.WithInsecure())
+	ta.Nil(err)
+	defer conn.Close()
+
+	cli := NewAdminClient(conn)
+	ctx := context.Background()
+
+	keys, err := cli.ListKeys(ctx, &KeyRange{})
+	ta.Nil(err)
+	ta.Equal([]string{"bar", "foo"}, keys.Keys)
+
+	a, err := cli.GetInstance(ctx, &PaxosInstanceId{Key: "foo", Ver: 1})
+	ta.Nil(err)
+	ta.Equal(int64(1), a.Val.Vi64)
+	ta.Equal(int64(2), a.VBal.ProposerId)
+	ta.Equal(a.VBal.N, a.LastBal.N)
+
+	_, err = cli.GetInstance(ctx, &PaxosInstanceId{Key: "foo", Ver: 5})
+	ta.Equal(codes.NotFound, status.Code(err))
+
+	st, err := cli.Stats(ctx, &StatsRequest{})
+	ta.Nil(err)
+	ta.Equal(int64(0), st.Id)
+	ta.Equal(int64(2), st.Keys)
+	ta.Equal(int64(3), st.Instances)
+	ta.Equal(int64(3), st.Committed)
+}
+
+func TestAdminService_Token(t *testing.T) {
+
+	ta := require.New(t)
+
+	kvs, err := NewKVServer("")
+	ta.Nil(err)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	ta.Nil(err)
+
+	gs := grpc.NewServer()
+	RegisterAdminServer(gs, NewAdminService(kvs, "secret"))
+	go gs.Serve(lis)
+	defer gs.Stop()
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	ta.Nil(err)
+	defer conn.Close()
+
+	cli := NewAdminClient(conn)
+	ctx := context.Background()
+
+	_, err = cli.Stats(ctx, &StatsRequest{})
+	ta.Equal(codes.Unauthenticated, status.Code(err))
+
+	_, err = cli.Stats(WithAdminToken(ctx, "wrong"), &StatsRequest{})
+	ta.Equal(codes.Unauthenticated, status.Code(err))
+
+	_, err = cli.ListKeys(WithAdminToken(ctx, "wrong"), &KeyRange{})
+	ta.Equal(codes.Unauthenticated, status.Code(err))
+
+	st, err := cli.Stats(WithAdminToken(ctx, "secret"), &StatsRequest{})
+	ta.Nil(err)
+	ta.Equal(int64(0), st.Keys)
+}
+
+func TestAdminService_ACL(t *testing.T) {
+
+	ta := require.New(t)
+
+	acl, err := ParseACL(strings.NewReader(testACL))
+	ta.Nil(err)
+
+	kvs, err := NewKVServer("")
+	ta.Nil(err)
+	kvs.ACL = acl
+	for _, k := range []string{"user/alice/x", "user/bob/x"} {
+		kvs.apply(&LogEntry{Instance: &Instance{
+			Id:       &PaxosInstanceId{Key: k, Ver: 0},
+			Acceptor: &Acceptor{Val: &Value{Vi64: 1}, Committed: true},
+		}})
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	ta.Nil(err)
+
+	gs := grpc.NewServer()
+	RegisterAdminServer(gs, NewAdminService(kvs, "secret"))
+	go gs.Serve(lis)
+	defer gs.Stop()
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	ta.Nil(err)
+	defer conn.Close()
+
+	cli := NewAdminClient(conn)
+	admin := WithAdminToken(context.Background(), "secret")
+	bob := WithAdminToken(WithToken(context.Background(), "b-token"), "secret")
+
+	keys, err := cli.ListKeys(bob, &KeyRange{})
+	ta.Nil(err)
+	ta.Equal([]string{"user/bob/x"}, keys.Keys)
+
+	keys, err = cli.ListKeys(admin, &KeyRange{})
+	ta.Nil(err)
+	ta.Equal(0, len(keys.Keys), "the admin token is no identity")
+
+	a, err := cli.GetInstance(bob, &PaxosInstanceId{Key: "user/bob/x", Ver: 0})
+	ta.Nil(err)
+	ta.Equal(int64(1), a.Val.Vi64)
+
+	_, err = cli.GetInstance(bob, &PaxosInstanceId{Key: "user/alice/x", Ver: 0})
+	ta.Equal(codes.PermissionDenied, status.Code(err))
+
+	_, err = cli.GetInstance(admin, &PaxosInstanceId{Key: "user/bob/x", Ver: 0})
+	ta.Equal(codes.PermissionDenied, status.Code(err))
+}
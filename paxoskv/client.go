@@ -0,0 +1,475 @@
+package paxoskv
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	NotFound = errors.New("not found")
+)
+
+// internalKeyPrefix is the prefix of keys paxoskv uses internally, such as
+// transaction records. User keys must not start with it.
+const internalKeyPrefix = "\x00"
+
+// KVClient is a key-value client built on RunPaxos.
+//
+// Every version of a key is a paxos instance. The latest version of a key is
+// the highest version that has a value chosen. Writing a key is choosing a
+// value for the version next to the latest one.
+//
+// Removing a key is writing a tombstone: a Value with `Deleted` set.
+type KVClient struct {
+	// AcceptorIds are the Acceptors this client runs paxos with, if Shards is
+	// nil.
+	AcceptorIds []int64
+
+	// Shards routes every key to the acceptor group of the shard the key is
+	// in. If it is nil, all keys go to AcceptorIds.
+	Shards *ShardMap
+
+	// MetaAcceptorIds are the Acceptors storing the shard map, if not empty.
+	// Shards is reloaded from them when a shard is moved.
+	MetaAcceptorIds []int64
+
+	// shardsMu protects Shards from being replaced by a reload.
+	shardsMu sync.Mutex
+
+	// meta is the client to access the shard map in MetaAcceptorIds.
+	meta *KVClient
+
+	// Logger receives the log entries of this client and the Proposers it
+	// runs. If it is nil, the Logger set by SetLogger is used.
+	Logger Logger
+
+	// Tracer traces the Proposers this client runs. If it is nil, the Tracer
+	// set by SetTracer is used.
+	Tracer *Tracer
+
+	// ProposerId is the ProposerId in every ballot number this client uses.
+	// It must be unique among all proposers.
+	ProposerId int64
+
+	// StateFile, if not empty, is where the ProposerId and the highest ballot
+	// N this client may have used are stored, thus a restarted client never
+	// reuses a ballot. See LoadState.
+	StateFile string
+
+	// balOwner, if not nil, is the client this client takes ballots from,
+	// instead of from its own bal.
+	balOwner *KVClient
+
+	// balMu protects bal and balLimit.
+	balMu sync.Mutex
+
+	// bal is the last ballot N this client used.
+	bal int64
+
+	// balLimit is the highest ballot N stored in StateFile.
+	balLimit int64
+
+	// seq is the last sequence number this client used to build an id.
+	seq int64
+}
+
+// Get returns the value and the version of the latest visible version of a
+// key. A version written by a transaction is visible only if the transaction
+// committed.
+// If the key is never written or the latest visible version is a tombstone or
+// is expired, it returns a NotFound error, along with the version of the
+// tombstone, or -1.
+//
+// Get writes nothing unless the visible version is expired: running paxos to
+// read a version only finishes a value already proposed, and a transaction not
+// yet decided is not aborted.
+// Clocks of readers differ, thus an expired version is not taken as deleted by
+// the clock of one reader. Get chooses a tombstone for the next version instead,
+// as a writer does, and returns what is chosen: once a reader finds the key
+// deleted, every later reader does.
+func (c *KVClient) Get(key string) (*Value, int64, error) {
+	return c.GetContext(context.Background(), key)
+}
+
+// GetContext is the same as Get except that it gives up when `ctx` is done, and
+// returns the error of `ctx`.
+func (c *KVClient) GetContext(ctx context.Context, key string) (*Value, int64, error) {
+	v, ver, _, err := c.view(ctx, key, false)
+	if err != nil {
+		return nil, -1, err
+	}
+	if v != nil && !v.Deleted && v.expired(time.Now()) {
+		v, ver, _, err = c.read(ctx, key)
+		if err != nil {
+			return nil, -1, err
+		}
+	}
+	if v == nil || v.Deleted {
+		return nil, ver, NotFound
+	}
+	return v, ver, nil
+}
+
+// Set writes `val` as the next version of a key and returns the version it
+// wrote.
+// A transaction not yet decided with an intent on the key is aborted, since the
+// intent would be hidden by the written version.
+func (c *KVClient) Set(key string, val *Value) (int64, error) {
+	return c.SetContext(context.Background(), key, val)
+}
+
+// SetContext is the same as Set except that it gives up when `ctx` is done, and
+// returns the error of `ctx`. `val` may have been chosen if it gives up after
+// proposing it.
+func (c *KVClient) SetContext(ctx context.Context, key string, val *Value) (int64, error) {
+	_, _, ver, err := c.view(ctx, key, true)
+	if err != nil {
+		return -1, err
+	}
+	return c.setFrom(ctx, key, ver+1, val)
+}
+
+// SetTTL is the same as Set except that the written version expires after
+// `ttl`: readers find the key deleted from then on. A tombstone is chosen for
+// the next version by the next writer of the key, or by Expire.
+//
+// The expiry time is chosen along with the value thus every reader agrees on
+// it, and the expiration is decided by paxos, not by the clock of an Acceptor.
+func (c *KVClient) SetTTL(key string, val *Value, ttl time.Duration) (int64, error) {
+	val = proto.Clone(val).(*Value)
+	val.ExpireAt = time.Now().Add(ttl).UnixNano()
+	return c.Set(key, val)
+}
+
+// Delete writes a tombstone as the next version of a key and returns the
+// version of the tombstone.
+// If the key is absent or already deleted, it returns a NotFound error and
+// writes nothing.
+func (c *KVClient) Delete(key string) (int64, error) {
+	return c.DeleteContext(context.Background(), key)
+}
+
+// DeleteContext is the same as Delete except that it gives up when `ctx` is
+// done, and returns the error of `ctx`. The tombstone may have been chosen if
+// it gives up after proposing it.
+func (c *KVClient) DeleteContext(ctx context.Context, key string) (int64, error) {
+	v, vver, ver, err := c.read(ctx, key)
+	if err != nil {
+		return -1, err
+	}
+	if v == nil || v.Deleted {
+		return vver, NotFound
+	}
+	return c.setFrom(ctx, key, ver+1, &Value{Deleted: true})
+}
+
+// view returns the latest visible value of a key and its version, along with
+// the latest version, which is greater than the visible one if there are
+// versions written by uncommitted transactions. An expired value is returned
+// as it is.
+//
+// With `decide`, a transaction not yet decided is aborted. Without it, its
+// intents are skipped: a reader sees the versions before them until the
+// transaction is decided.
+//
+// All the methods running paxos return the error of `ctx` once it is done.
+func (c *KVClient) view(ctx context.Context, key string, decide bool) (*Value, int64, int64, error) {
+	latest, ver, err := c.latest(ctx, key)
+	if err != nil {
+		return nil, -1, -1, err
+	}
+	v, vver, err := c.visible(ctx, key, latest, ver, decide)
+	return v, vver, ver, err
+}
+
+// read is the same as view for a writer, which must not write over an intent
+// of a transaction not yet decided: such a transaction is aborted.
+//
+// If the visible value is expired, it tries to choose a tombstone for the
+// version after the latest one, then reads again.
+func (c *KVClient) read(ctx context.Context, key string) (*Value, int64, int64, error) {
+	for {
+		v, vver, ver, err := c.view(ctx, key, true)
+		if err != nil {
+			return nil, -1, -1, err
+		}
+
+		if v != nil && !v.Deleted && v.expired(time.Now()) {
+			c.log(LevelDebug, "KVClient: expired, write a tombstone", F("key", key), F("ver", vver), F("tombstone", ver+1))
+			if _, err := c.runPaxos(ctx, key, ver+1, &Value{Deleted: true}); err != nil {
+				return nil, -1, -1, err
+			}
+			continue
+		}
+
+		return v, vver, ver, nil
+	}
+}
+
+// expired returns whether a Value with an expiry time is expired at `now`.
+func (v *Value) expired(now time.Time) bool {
+	return v.ExpireAt != 0 && now.UnixNano() >= v.ExpireAt
+}
+
+// latest reads versions of a key up, until it finds a version without any
+// value voted. Versions are chosen one after another, thus it starts from the
+// highest version the Acceptors know to be chosen, instead of from 0.
+// It returns the value of the last version it read and the version, or nil and
+// -1 if the key is absent.
+func (c *KVClient) latest(ctx context.Context, key string) (*Value, int64, error) {
+	var latest *Value
+	ver := c.latestHint(key)
+	for {
+		v, err := c.runPaxos(ctx, key, ver, nil)
+		if err != nil {
+			return nil, -1, err
+		}
+		if v == nil && latest == nil && ver > 0 {
+			c.log(LevelWarn, "KVClient: no value at hinted version, read from 0", F("key", key), F("ver", ver))
+			ver = 0
+			continue
+		}
+		if v == nil {
+			return latest, ver - 1, nil
+		}
+		latest = v
+		ver++
+	}
+}
+
+// latestHint returns the highest version of a key an Acceptor of its group
+// knows to be chosen, or 0. Acceptors failing to reply are skipped.
+func (c *KVClient) latestHint(key string) int64 {
+	hint := int64(0)
+	for _, aid := range c.acceptorsOf(key) {
+		var reply *PaxosInstanceId
+		err := callAcceptor(aid, func(ctx context.Context, cli PaxosKVClient) (err error) {
+			reply, err = cli.LatestChosen(ctx, &PaxosInstanceId{Key: key})
+			return err
+		})
+		if err != nil {
+			c.log(LevelDebug, "KVClient: LatestChosen failure", F("acceptor", aid), F("key", key), F("err", err))
+			continue
+		}
+		if reply.Ver > hint {
+			hint = reply.Ver
+		}
+	}
+	return hint
+}
+
+// visible walks from version `ver` of a key, whose value is `v`, down to the
+// first version that is not written by an uncommitted transaction.
+// With `decide`, a transaction not yet decided is aborted, otherwise it is
+// only skipped.
+// It returns the value and the version it found, or nil and -1.
+func (c *KVClient) visible(ctx context.Context, key string, v *Value, ver int64, decide bool) (*Value, int64, error) {
+	for ; ver >= 0; ver-- {
+		if v == nil {
+			var err error
+			v, err = c.runPaxos(ctx, key, ver, nil)
+			if err != nil {
+				return nil, -1, err
+			}
+		}
+		if v.TxnId == "" {
+			return v, ver, nil
+		}
+
+		var committed bool
+		var err error
+		if decide {
+			committed, err = c.txnCommitted(ctx, v.TxnId)
+		} else {
+			committed, err = c.txnSeenCommitted(ctx, v.TxnId)
+		}
+		if err != nil {
+			return nil, -1, err
+		}
+		if committed {
+			return v, ver, nil
+		}
+		c.log(LevelDebug, "KVClient: skip version written by uncommitted txn", F("key", key), F("ver", ver), F("txn", v.TxnId))
+		v = nil
+	}
+	return nil, -1, nil
+}
+
+// setFrom tries to choose `val` for version `ver` of a key.
+// If another value is chosen for `ver`, it tries the next version, until `val`
+// is chosen. It returns the version at which `val` is chosen.
+// An intent chosen by another transaction is decided before it is written
+// over.
+func (c *KVClient) setFrom(ctx context.Context, key string, ver int64, val *Value) (int64, error) {
+	for {
+		// RunPaxos returns the value voted by others if there is one.
+		v, err := c.runPaxos(ctx, key, ver, val)
+		if err != nil {
+			return -1, err
+		}
+		if proto.Equal(v, val) {
+			return ver, nil
+		}
+		c.log(LevelDebug, "KVClient: chosen by other, try next version", F("key", key), F("ver", ver), F("val", v))
+		if v.TxnId != "" {
+			if _, err := c.txnCommitted(ctx, v.TxnId); err != nil {
+				return -1, err
+			}
+		}
+		ver++
+	}
+}
+
+// runPaxos runs a paxos on a version of a key and returns the chosen value.
+//
+// A compacted version reads as a tombstone: an Acceptor compacts only the
+// versions up to a chosen tombstone, and nothing can be chosen for them any
+// more. Thus a reader goes on to the next version and a writer writes after
+// them.
+//
+// The only error it returns is the error of `ctx`, once `ctx` is done.
+func (c *KVClient) runPaxos(ctx context.Context, key string, ver int64, val *Value) (*Value, error) {
+	v, _, err := c.choose(ctx, key, ver, val)
+	if err == InstanceCompacted {
+		return &Value{Deleted: true}, nil
+	}
+	return v, err
+}
+
+// choose runs a paxos on a version of a key and returns the chosen value and
+// the ballot number at which it is chosen.
+//
+// If the key has been moved to another acceptor group, it reloads the shard
+// map and runs the paxos again on the same version in the new group. The move
+// brings every value chosen or voted in the old group to the new group, thus
+// resuming the same instance neither loses nor repeats a write.
+//
+// If the instance is compacted by an Acceptor, nothing can be chosen and it
+// returns an InstanceCompacted error. If a quorum fails to store the instance,
+// it retries after a while.
+//
+// It retries until `ctx` is done, then returns the error of `ctx`.
+func (c *KVClient) choose(ctx context.Context, key string, ver int64, val *Value) (*Value, *BallotNum, error) {
+	for {
+		if err := ctx.Err(); err != nil {
+			c.log(LevelDebug, "KVClient: give up", F("key", key), F("ver", ver), F("err", err))
+			return nil, nil, err
+		}
+
+		p, err := c.newProposer(key, ver)
+		if err != nil {
+			c.log(LevelWarn, "KVClient: fail to create proposer, retry", F("key", key), F("ver", ver), F("err", err))
+			sleep(ctx, 10*time.Millisecond)
+			continue
+		}
+
+		v, bal, err := p.runPaxos(c.acceptorsOf(key), val, c.env(ctx))
+		if err == nil {
+			return v, bal, nil
+		}
+		if err == InstanceCompacted {
+			c.log(LevelDebug, "KVClient: instance compacted", F("key", key), F("ver", ver))
+			return nil, nil, err
+		}
+		if err == context.Canceled || err == context.DeadlineExceeded {
+			return nil, nil, err
+		}
+		if err == StorageFailure {
+			c.log(LevelError, "KVClient: Acceptors fail to store, retry", F("key", key), F("ver", ver))
+			sleep(ctx, 100*time.Millisecond)
+			continue
+		}
+		c.log(LevelWarn, "KVClient: reload shard map and retry", F("key", key), F("ver", ver), F("err", err))
+		c.reloadShards()
+	}
+}
+
+// env returns the runEnv of the Proposers this client runs, which give up
+// when `ctx` is done.
+func (c *KVClient) env(ctx context.Context) *runEnv {
+	return &runEnv{ctx: ctx, logger: loggerOr(c.Logger), tracer: tracerOr(c.Tracer), next: c.nextBal}
+}
+
+// sleep waits for `d`, or until `ctx` is done.
+func sleep(ctx context.Context, d time.Duration) {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+	case <-ctx.Done():
+	}
+}
+
+// log logs an entry of this client.
+func (c *KVClient) log(level Level, msg string, fields ...Field) {
+	loggerOr(c.Logger).Log(level, msg, fields...)
+}
+
+// acceptorsOf returns the Acceptors to run paxos with for a key.
+func (c *KVClient) acceptorsOf(key string) []int64 {
+	m := c.shardMap()
+	return m.Groups[m.Lookup(key).GroupId]
+}
+
+// shardMap returns Shards, or a ShardMap with only one shard served by
+// AcceptorIds if Shards is nil.
+func (c *KVClient) shardMap() *ShardMap {
+	c.shardsMu.Lock()
+	defer c.shardsMu.Unlock()
+
+	if c.Shards != nil {
+		return c.Shards
+	}
+	return &ShardMap{
+		Groups: map[int64][]int64{0: c.AcceptorIds},
+		Shards: []*Shard{{}},
+	}
+}
+
+// CompareAndSet writes `val` as version `ver`+1 of a key, only if `ver` is the
+// latest version, i.e., no version after `ver` is written yet. `ver` is -1 for
+// an absent key.
+// It returns whether `val` is written.
+func (c *KVClient) CompareAndSet(key string, ver int64, val *Value) (bool, error) {
+	return c.compareAndSet(context.Background(), key, ver, val)
+}
+
+// compareAndSet is the same as CompareAndSet except that it gives up when `ctx`
+// is done, and returns the error of `ctx`.
+func (c *KVClient) compareAndSet(ctx context.Context, key string, ver int64, val *Value) (bool, error) {
+	val = proto.Clone(val).(*Value)
+	val.WriteId = c.newId()
+
+	v, err := c.runPaxos(ctx, key, ver+1, val)
+	if err != nil {
+		return false, err
+	}
+	return proto.Equal(v, val), nil
+}
+
+// newId returns an id unique among all clients, as long as ProposerId is.
+func (c *KVClient) newId() string {
+	return fmt.Sprintf("%d-%d-%d", c.ProposerId, time.Now().UnixNano(), atomic.AddInt64(&c.seq, 1))
+}
+
+// newProposer creates a Proposer for a version of a key, with a ballot number
+// no other paxos by this client has used.
+func (c *KVClient) newProposer(key string, ver int64) (*Proposer, error) {
+	n, err := c.nextBal(0)
+	if err != nil {
+		return nil, err
+	}
+	return &Proposer{
+		Id: &PaxosInstanceId{
+			Key: key,
+			Ver: ver,
+		},
+		Bal: &BallotNum{N: n, ProposerId: c.ProposerId},
+	}, nil
+}
@@ -0,0 +1,285 @@
+package paxoskv
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKVClient_SetGetDelete(t *testing.T) {
+
+	ta := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+
+	servers, err := ServeAcceptors(acceptorIds)
+	ta.Nil(err)
+	defer func() {
+		for _, s := range servers {
+			s.Stop()
+		}
+	}()
+
+	c := &KVClient{AcceptorIds: acceptorIds, ProposerId: 2}
+
+	_, ver, err := c.Get("foo")
+	ta.Equal(NotFound, err, "absent key")
+	ta.Equal(int64(-1), ver)
+
+	_, err = c.Delete("foo")
+	ta.Equal(NotFound, err, "nothing to delete")
+
+	ver, err = c.Set("foo", &Value{Vi64: 5})
+	ta.Nil(err)
+	ta.Equal(int64(0), ver)
+
+	ver, err = c.Set("foo", &Value{Vi64: 6})
+	ta.Nil(err)
+	ta.Equal(int64(1), ver)
+
+	v, ver, err := c.Get("foo")
+	ta.Nil(err)
+	ta.Equal(int64(6), v.Vi64)
+	ta.Equal(int64(1), ver)
+
+	ver, err = c.Delete("foo")
+	ta.Nil(err)
+	ta.Equal(int64(2), ver, "tombstone is the next version")
+
+	_, ver, err = c.Get("foo")
+	ta.Equal(NotFound, err, "tombstoned key")
+	ta.Equal(int64(2), ver)
+
+	_, err = c.Delete("foo")
+	ta.Equal(NotFound, err, "already deleted")
+
+	ver, err = c.Set("foo", &Value{Vi64: 7})
+	ta.Nil(err)
+	ta.Equal(int64(3), ver, "write after the tombstone")
+
+	v, _, err = c.Get("foo")
+	ta.Nil(err)
+	ta.Equal(int64(7), v.Vi64)
+}
+
+func TestKVClient_LatestHint(t *testing.T) {
+
+	ta := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+
+	servers, err := ServeAcceptors(acceptorIds)
+	ta.Nil(err)
+	defer func() {
+		for _, s := range servers {
+			s.Stop()
+		}
+	}()
+
+	c := &KVClient{AcceptorIds: acceptorIds, ProposerId: 2}
+
+	for i := int64(0); i < 100; i++ {
+		ver, err := c.Set("long", &Value{Vi64: i})
+		ta.Nil(err)
+		ta.Equal(i, ver)
+	}
+
+	prepares := rpcDuration.get("0", "Prepare")
+
+	v, ver, err := c.Get("long")
+	ta.Nil(err)
+	ta.Equal(int64(99), v.Vi64)
+	ta.Equal(int64(99), ver)
+	ta.InDelta(prepares, rpcDuration.get("0", "Prepare"), 3, "reading starts from the latest chosen version")
+
+	ver, err = c.Set("long", &Value{Vi64: 100})
+	ta.Nil(err)
+	ta.Equal(int64(100), ver)
+
+	reply, err := servers[0].KVServer.LatestChosen(nil, &PaxosInstanceId{Key: "long"})
+	ta.Nil(err)
+	ta.Equal(int64(100), reply.Ver)
+
+	reply, err = servers[0].KVServer.LatestChosen(nil, &PaxosInstanceId{Key: "absent"})
+	ta.Nil(err)
+	ta.Equal(int64(-1), reply.Ver)
+}
+
+func TestKVClient_SetTTL(t *testing.T) {
+
+	ta := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+
+	servers, err := ServeAcceptors(acceptorIds)
+	ta.Nil(err)
+	defer func() {
+		for _, s := range servers {
+			s.Stop()
+		}
+	}()
+
+	c := &KVClient{AcceptorIds: acceptorIds, ProposerId: 2}
+
+	ver, err := c.SetTTL("svc", &Value{Vi64: 5}, 200*time.Millisecond)
+	ta.Nil(err)
+	ta.Equal(int64(0), ver)
+
+	v, _, err := c.Get("svc")
+	ta.Nil(err)
+	ta.Equal(int64(5), v.Vi64)
+	ta.NotEqual(int64(0), v.ExpireAt)
+
+	// the owner renews it before it expires.
+
+	time.Sleep(100 * time.Millisecond)
+	ver, err = c.SetTTL("svc", &Value{Vi64: 5}, 200*time.Millisecond)
+	ta.Nil(err)
+	ta.Equal(int64(1), ver)
+
+	time.Sleep(150 * time.Millisecond)
+	_, _, err = c.Get("svc")
+	ta.Nil(err, "renewed")
+
+	_, err = c.SetTTL("job", &Value{Vi64: 6}, 200*time.Millisecond)
+	ta.Nil(err)
+
+	// the owner dies.
+
+	time.Sleep(250 * time.Millisecond)
+	_, ver, err = c.Get("svc")
+	ta.Equal(NotFound, err, "expired")
+	ta.Equal(int64(2), ver, "a reader chooses a tombstone for the next version")
+
+	records, err := c.History("svc", 2, 2)
+	ta.Nil(err)
+	ta.True(records[0].Val.Deleted)
+
+	n, err := c.Expire("", "")
+	ta.Nil(err)
+	ta.Equal(1, n, "job is not read since it expires")
+
+	_, ver, err = c.Get("job")
+	ta.Equal(NotFound, err)
+	ta.Equal(int64(1), ver)
+
+	n, err = c.Expire("", "")
+	ta.Nil(err)
+	ta.Equal(0, n, "already deleted")
+}
+
+func TestKVServer_GC(t *testing.T) {
+
+	ta := require.New(t)
+
+	newVersion := func(val *Value) *Version {
+		return &Version{
+			acceptor: Acceptor{
+				LastBal:   &BallotNum{N: 1},
+				VBal:      &BallotNum{N: 1},
+				Val:       val,
+				Committed: true,
+			},
+		}
+	}
+
+	kvs := KVServer{
+		Storage: map[string]Versions{
+			"alive": {
+				0: newVersion(&Value{Vi64: 1}),
+			},
+			"deleted": {
+				0: newVersion(&Value{Vi64: 1}),
+				1: newVersion(&Value{Deleted: true}),
+			},
+			"recreated": {
+				0: newVersion(&Value{Deleted: true}),
+				1: newVersion(&Value{Vi64: 2}),
+			},
+			"voted": {
+				0: {acceptor: Acceptor{LastBal: &BallotNum{N: 1}, VBal: &BallotNum{N: 1}, Val: &Value{Deleted: true}}},
+			},
+		},
+	}
+
+	ta.Equal([]string{}, kvs.GC(), "the first GC only marks tombstoned keys")
+	ta.Contains(kvs.Storage, "deleted")
+
+	ta.Equal([]string{"deleted"}, kvs.GC())
+	ta.NotContains(kvs.Storage, "deleted")
+	ta.Contains(kvs.Storage, "alive")
+	ta.Contains(kvs.Storage, "recreated")
+	ta.Contains(kvs.Storage, "voted", "a tombstone not committed may not be chosen")
+
+	// the dropped versions are refused, the next one is not.
+
+	for ver := int64(0); ver <= 1; ver++ {
+		reply, err := kvs.Prepare(nil, &Proposer{Id: &PaxosInstanceId{Key: "deleted", Ver: ver}, Bal: &BallotNum{N: 5}})
+		ta.Nil(err)
+		ta.Equal(AcceptorStatus_InstanceCompacted, reply.Status)
+	}
+	reply, err := kvs.Prepare(nil, &Proposer{Id: &PaxosInstanceId{Key: "deleted", Ver: 2}, Bal: &BallotNum{N: 5}})
+	ta.Nil(err)
+	ta.Equal(AcceptorStatus_Accepted, reply.Status)
+	delete(kvs.Storage, "deleted")
+
+	// a newer version written after a GC saw the tombstone keeps the key.
+
+	kvs.Storage["deleted"] = Versions{0: newVersion(&Value{Deleted: true})}
+	ta.Equal([]string{}, kvs.GC())
+	kvs.Storage["deleted"][1] = newVersion(nil)
+	ta.Equal([]string{}, kvs.GC())
+	ta.Contains(kvs.Storage, "deleted")
+}
+
+func TestKVClient_GC(t *testing.T) {
+
+	ta := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+
+	servers, err := ServeAcceptors(acceptorIds)
+	ta.Nil(err)
+	defer func() {
+		for _, s := range servers {
+			s.Stop()
+		}
+	}()
+
+	c := &KVClient{AcceptorIds: acceptorIds, ProposerId: 2}
+
+	_, err = c.Set("foo", &Value{Vi64: 5})
+	ta.Nil(err)
+	ver, err := c.Delete("foo")
+	ta.Nil(err)
+	ta.Equal(int64(1), ver)
+
+	// a quorum drops the key, Acceptor-2 keeps it.
+	for _, s := range servers[:2] {
+		s.KVServer.GC()
+		ta.Equal([]string{"foo"}, s.KVServer.GC())
+	}
+
+	_, ver, err = c.Get("foo")
+	ta.Equal(NotFound, err)
+	ta.Equal(int64(1), ver)
+
+	ver, err = c.Set("foo", &Value{Vi64: 6})
+	ta.Nil(err)
+	ta.Equal(int64(2), ver, "the compacted versions are not written again")
+
+	// every quorum agrees on every version.
+	for _, ids := range [][]int64{{0, 1}, {0, 2}, {1, 2}} {
+		c := &KVClient{AcceptorIds: ids, ProposerId: 3}
+		v, ver, err := c.Get("foo")
+		ta.Nil(err, "%v", ids)
+		ta.Equal(int64(6), v.Vi64)
+		ta.Equal(int64(2), ver)
+	}
+
+	records, err := c.History("foo", 0, -1)
+	ta.Nil(err)
+	ta.Equal(1, len(records), "compacted versions are skipped")
+	ta.Equal(int64(2), records[0].Ver)
+}
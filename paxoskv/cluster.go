@@ -0,0 +1,137 @@
+package paxoskv
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Cluster is the address of every Acceptor, and the names in the certificates
+// of the peers allowed to connect with TLS.
+//
+// A cluster file has one Acceptor per line: its id, its address and,
+// optionally, the name in its certificate, separated by spaces. A line
+// "peer <name>" allows a proposer that is not an Acceptor, e.g., a command-line
+// client, to connect with a certificate of the name. E.g.:
+//
+//	# id address [name]
+//	0 10.0.0.1:3333 acceptor-0.paxoskv
+//	1 10.0.0.2:3333 acceptor-1.paxoskv
+//	peer client.paxoskv
+//
+// Empty lines and lines starting with "#" are ignored.
+type Cluster struct {
+	Addrs map[int64]string
+
+	// Names are the names in the certificates of the Acceptors.
+	Names map[int64]string
+
+	// Peers are the names in the certificates of other proposers.
+	Peers []string
+}
+
+var (
+	// clusterMu protects cluster.
+	clusterMu sync.RWMutex
+
+	// cluster is the Cluster set by UseCluster.
+	cluster *Cluster
+)
+
+// LoadCluster reads a cluster file.
+func LoadCluster(path string) (*Cluster, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ParseCluster(f)
+}
+
+// ParseCluster reads a Cluster in the format of a cluster file.
+func ParseCluster(r io.Reader) (*Cluster, error) {
+
+	c := &Cluster{Addrs: map[int64]string{}, Names: map[int64]string{}}
+
+	scanner := bufio.NewScanner(r)
+	lineno := 0
+	for scanner.Scan() {
+		lineno++
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if fields[0] == "peer" {
+			if len(fields) != 2 {
+				return nil, fmt.Errorf("line %d: expect peer <name>: %q", lineno, line)
+			}
+			c.Peers = append(c.Peers, fields[1])
+			continue
+		}
+		if len(fields) != 2 && len(fields) != 3 {
+			return nil, fmt.Errorf("line %d: expect <id> <address> [name]: %q", lineno, line)
+		}
+
+		id, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid id: %w", lineno, err)
+		}
+		if _, found := c.Addrs[id]; found {
+			return nil, fmt.Errorf("line %d: duplicate id: %d", lineno, id)
+		}
+		c.Addrs[id] = fields[1]
+		if len(fields) == 3 {
+			c.Names[id] = fields[2]
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// UseCluster makes Proposers and clients connect to Acceptors at the addresses
+// in `c`. An Acceptor not in `c`, or any Acceptor if `c` is nil, is at port
+// AcceptorBasePort+id on localhost.
+func UseCluster(c *Cluster) {
+	clusterMu.Lock()
+	defer clusterMu.Unlock()
+	cluster = c
+}
+
+// AcceptorIds returns the ids of all Acceptors in order.
+func (c *Cluster) AcceptorIds() []int64 {
+	ids := make([]int64, 0, len(c.Addrs))
+	for id := range c.Addrs {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// allowed returns whether any of `names` is the name of an Acceptor or a peer.
+func (c *Cluster) allowed(names []string) bool {
+	for _, n := range names {
+		for _, name := range c.Names {
+			if n == name {
+				return true
+			}
+		}
+		for _, name := range c.Peers {
+			if n == name {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+package paxoskv
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCluster(t *testing.T) {
+
+	ta := require.New(t)
+
+	c, err := ParseCluster(strings.NewReader(`
+# id address [name]
+1 10.0.0.2:3333 acceptor-1
+0   10.0.0.1:3333
+peer client
+`))
+	ta.Nil(err)
+	ta.Equal(map[int64]string{0: "10.0.0.1:3333", 1: "10.0.0.2:3333"}, c.Addrs)
+	ta.Equal(map[int64]string{1: "acceptor-1"}, c.Names)
+	ta.Equal([]string{"client"}, c.Peers)
+	ta.Equal([]int64{0, 1}, c.AcceptorIds())
+
+	ta.True(c.allowed([]string{"x", "acceptor-1"}))
+	ta.True(c.allowed([]string{"client"}))
+	ta.False(c.allowed([]string{"acceptor-0"}))
+
+	for _, bad := range []string{
+		"0",
+		"a 10.0.0.1:3333",
+		"0 10.0.0.1:3333 x y",
+		"peer",
+		"0 10.0.0.1:3333\n0 10.0.0.2:3333",
+	} {
+		_, err := ParseCluster(strings.NewReader(bad))
+		ta.NotNil(err, bad)
+	}
+
+	UseCluster(c)
+	defer UseCluster(nil)
+
+	ta.Equal("10.0.0.2:3333", acceptorAddr(1))
+	ta.Equal("127.0.0.1:3335", acceptorAddr(2))
+}
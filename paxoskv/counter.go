@@ -0,0 +1,38 @@
+package paxoskv
+
+import "golang.org/x/net/context"
+
+// Increment adds `delta` to the int64 value of a key and returns the new value.
+// An absent or deleted key counts as 0.
+//
+// It reads the latest version and writes the sum as the next version with
+// CompareAndSet, and retries if another version is written first. Since every
+// version is chosen once, concurrent increments never lose an update nor
+// return the same value, which makes it suitable for id allocation.
+func (c *KVClient) Increment(key string, delta int64) (int64, error) {
+	return c.increment(context.Background(), key, delta)
+}
+
+// increment is the same as Increment except that it gives up when `ctx` is
+// done, and returns the error of `ctx`.
+func (c *KVClient) increment(ctx context.Context, key string, delta int64) (int64, error) {
+	for {
+		v, _, ver, err := c.read(ctx, key)
+		if err != nil {
+			return 0, err
+		}
+
+		n := delta
+		if v != nil && !v.Deleted {
+			n += v.Vi64
+		}
+
+		ok, err := c.compareAndSet(ctx, key, ver, &Value{Vi64: n})
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			return n, nil
+		}
+	}
+}
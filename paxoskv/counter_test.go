@@ -0,0 +1,71 @@
+package paxoskv
+
+import (
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKVClient_Increment(t *testing.T) {
+
+	ta := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+
+	servers, err := ServeAcceptors(acceptorIds)
+	ta.Nil(err)
+	defer func() {
+		for _, s := range servers {
+			s.Stop()
+		}
+	}()
+
+	c := &KVClient{AcceptorIds: acceptorIds, ProposerId: 2}
+
+	n, err := c.Increment("id", 1)
+	ta.Nil(err)
+	ta.Equal(int64(1), n, "an absent key counts as 0")
+
+	n, err = c.Increment("id", 10)
+	ta.Nil(err)
+	ta.Equal(int64(11), n)
+
+	// concurrent increments by different clients
+
+	nClient, nIncr := 3, 5
+
+	mu := sync.Mutex{}
+	got := []int64{}
+	errs := []error{}
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < nClient; i++ {
+		wg.Add(1)
+		go func(pid int64) {
+			defer wg.Done()
+			cli := &KVClient{AcceptorIds: acceptorIds, ProposerId: pid}
+			for j := 0; j < nIncr; j++ {
+				n, err := cli.Increment("id", 1)
+				mu.Lock()
+				if err != nil {
+					errs = append(errs, err)
+				}
+				got = append(got, n)
+				mu.Unlock()
+			}
+		}(int64(10 + i))
+	}
+	wg.Wait()
+	ta.Equal([]error{}, errs)
+
+	sort.Slice(got, func(i, j int) bool { return got[i] < got[j] })
+	for i, n := range got {
+		ta.Equal(int64(12+i), n, "every increment returns a distinct value")
+	}
+
+	v, _, err := c.Get("id")
+	ta.Nil(err)
+	ta.Equal(int64(11+nClient*nIncr), v.Vi64)
+}
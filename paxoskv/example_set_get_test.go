@@ -10,7 +10,10 @@ func Example_setAndGetByKeyVer() {
 
 	acceptorIds := []int64{0, 1, 2}
 
-	servers := ServeAcceptors(acceptorIds)
+	servers, err := ServeAcceptors(acceptorIds)
+	if err != nil {
+		panic(err)
+	}
 	defer func() {
 		for _, s := range servers {
 			s.Stop()
@@ -0,0 +1,250 @@
+package paxoskv
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// gatewayPrefix is the URL path prefix of keys in the HTTP gateway.
+const gatewayPrefix = "/v1/keys/"
+
+// Gateway serves the KV API over HTTP/JSON, with a KVClient:
+//
+//	PUT    /v1/keys/{key}                 body: {"value": 5, "ttl": "10s"}
+//	PUT    /v1/keys/{key}?prev_ver=N      compare-and-set: 409 if N is not the latest version, -1 for an absent key
+//	GET    /v1/keys/{key}                 the latest visible version
+//	GET    /v1/keys/{key}?ver=N           the value chosen for version N
+//	GET    /v1/keys/{key}?history         all chosen versions; narrowed by from=N and to=N
+//	DELETE /v1/keys/{key}
+//
+// A key that is absent or deleted is a 404. Every reply body is JSON: a
+// GatewayRecord, a list of them, or a GatewayError.
+type Gateway struct {
+	Client *KVClient
+
+	// ACL, if not nil, is the permissions on keys required by requests: read
+	// for GET and write for PUT and DELETE. The identity is the token in the
+	// header "Authorization: Bearer <token>" or the TLS client certificate.
+	// An unknown token is a 401, a request without permission a 403.
+	ACL *ACL
+}
+
+// GatewayRecord is a version of a key in the HTTP gateway.
+type GatewayRecord struct {
+	Key     string     `json:"key"`
+	Ver     int64      `json:"ver"`
+	Value   int64      `json:"value"`
+	Deleted bool       `json:"deleted,omitempty"`
+	Bal     *BallotNum `json:"bal,omitempty"`
+}
+
+// GatewayError is the body of a failed request in the HTTP gateway.
+type GatewayError struct {
+	Error string `json:"error"`
+}
+
+// gatewayPut is the body of a PUT request.
+type gatewayPut struct {
+	Value int64  `json:"value"`
+	TTL   string `json:"ttl,omitempty"`
+}
+
+// NewGateway creates an HTTP handler serving the KV API with `c`.
+func NewGateway(c *KVClient) *Gateway {
+	return &Gateway{Client: c}
+}
+
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	g.Client.log(LevelDebug, "Gateway: recv request", F("method", r.Method), F("url", r.URL.String()))
+
+	if !strings.HasPrefix(r.URL.Path, gatewayPrefix) {
+		writeJSON(w, http.StatusNotFound, &GatewayError{Error: "not found"})
+		return
+	}
+
+	key := strings.TrimPrefix(r.URL.Path, gatewayPrefix)
+	if key == "" {
+		writeJSON(w, http.StatusBadRequest, &GatewayError{Error: "empty key"})
+		return
+	}
+	if strings.HasPrefix(key, internalKeyPrefix) {
+		writeJSON(w, http.StatusBadRequest, &GatewayError{Error: "reserved key"})
+		return
+	}
+
+	perm := PermRead
+	if r.Method != http.MethodGet {
+		perm = PermWrite
+	}
+	if err := g.ACL.checkHTTP(r, key, perm); err != nil {
+		code := http.StatusForbidden
+		if status.Code(err) == codes.Unauthenticated {
+			code = http.StatusUnauthorized
+		}
+		writeJSON(w, code, &GatewayError{Error: status.Convert(err).Message()})
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		g.put(w, r, key)
+	case http.MethodGet:
+		g.get(w, r, key)
+	case http.MethodDelete:
+		g.delete(w, key)
+	default:
+		w.Header().Set("Allow", "GET, PUT, DELETE")
+		writeJSON(w, http.StatusMethodNotAllowed, &GatewayError{Error: "method not allowed"})
+	}
+}
+
+func (g *Gateway) put(w http.ResponseWriter, r *http.Request, key string) {
+
+	body := &gatewayPut{}
+	if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+		writeJSON(w, http.StatusBadRequest, &GatewayError{Error: "invalid body: " + err.Error()})
+		return
+	}
+
+	val := &Value{Vi64: body.Value}
+	if body.TTL != "" {
+		ttl, err := time.ParseDuration(body.TTL)
+		if err != nil || ttl <= 0 {
+			writeJSON(w, http.StatusBadRequest, &GatewayError{Error: "invalid ttl: " + body.TTL})
+			return
+		}
+		val.ExpireAt = time.Now().Add(ttl).UnixNano()
+	}
+
+	q := r.URL.Query()
+	if q.Has("prev_ver") {
+		prev, ok := queryInt(w, q.Get("prev_ver"), "prev_ver", -1)
+		if !ok {
+			return
+		}
+		set, err := g.Client.CompareAndSet(key, prev, val)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, &GatewayError{Error: err.Error()})
+			return
+		}
+		if !set {
+			writeJSON(w, http.StatusConflict, &GatewayError{Error: "version mismatch"})
+			return
+		}
+		writeJSON(w, http.StatusOK, &GatewayRecord{Key: key, Ver: prev + 1, Value: body.Value})
+		return
+	}
+
+	ver, err := g.Client.Set(key, val)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, &GatewayError{Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, &GatewayRecord{Key: key, Ver: ver, Value: body.Value})
+}
+
+func (g *Gateway) get(w http.ResponseWriter, r *http.Request, key string) {
+
+	q := r.URL.Query()
+
+	if q.Has("history") || q.Has("ver") {
+		from, to := int64(0), int64(-1)
+		ok := true
+		if q.Has("ver") {
+			from, ok = queryInt(w, q.Get("ver"), "ver", 0)
+			to = from
+		}
+		if ok && q.Has("from") {
+			from, ok = queryInt(w, q.Get("from"), "from", 0)
+		}
+		if ok && q.Has("to") {
+			to, ok = queryInt(w, q.Get("to"), "to", 0)
+		}
+		if !ok {
+			return
+		}
+
+		records, err := g.Client.History(key, from, to)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, &GatewayError{Error: err.Error()})
+			return
+		}
+
+		res := make([]*GatewayRecord, 0, len(records))
+		for _, rec := range records {
+			res = append(res, newGatewayRecord(rec))
+		}
+
+		if q.Has("history") {
+			writeJSON(w, http.StatusOK, res)
+			return
+		}
+		if len(res) == 0 {
+			writeJSON(w, http.StatusNotFound, &GatewayError{Error: NotFound.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, res[0])
+		return
+	}
+
+	v, ver, err := g.Client.Get(key)
+	if errors.Is(err, NotFound) {
+		writeJSON(w, http.StatusNotFound, &GatewayError{Error: err.Error()})
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, &GatewayError{Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, &GatewayRecord{Key: key, Ver: ver, Value: v.Vi64})
+}
+
+func (g *Gateway) delete(w http.ResponseWriter, key string) {
+	ver, err := g.Client.Delete(key)
+	if errors.Is(err, NotFound) {
+		writeJSON(w, http.StatusNotFound, &GatewayError{Error: err.Error()})
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, &GatewayError{Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, &GatewayRecord{Key: key, Ver: ver, Deleted: true})
+}
+
+func newGatewayRecord(r *Record) *GatewayRecord {
+	return &GatewayRecord{
+		Key:     r.Key,
+		Ver:     r.Ver,
+		Value:   r.Val.Vi64,
+		Deleted: r.Val.Deleted,
+		Bal:     r.Bal,
+	}
+}
+
+// queryInt parses an int64 query parameter not less than `min`, or replies a
+// 400.
+func queryInt(w http.ResponseWriter, s, name string, min int64) (int64, bool) {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < min {
+		writeJSON(w, http.StatusBadRequest, &GatewayError{Error: "invalid " + name + ": " + s})
+		return 0, false
+	}
+	return n, true
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		loggerOr(nil).Log(LevelWarn, "Gateway: fail to write reply", F("err", err))
+	}
+}
@@ -0,0 +1,86 @@
+package paxoskv
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGateway(t *testing.T) {
+
+	ta := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+
+	servers, err := ServeAcceptors(acceptorIds)
+	ta.Nil(err)
+	defer func() {
+		for _, s := range servers {
+			s.Stop()
+		}
+	}()
+
+	hs := httptest.NewServer(NewGateway(&KVClient{AcceptorIds: acceptorIds, ProposerId: 2}))
+	defer hs.Close()
+
+	do := func(method, path, body string, reply interface{}) int {
+		req, err := http.NewRequest(method, hs.URL+path, strings.NewReader(body))
+		ta.Nil(err)
+		resp, err := http.DefaultClient.Do(req)
+		ta.Nil(err)
+		defer resp.Body.Close()
+		if reply != nil {
+			ta.Nil(json.NewDecoder(resp.Body).Decode(reply))
+		}
+		return resp.StatusCode
+	}
+
+	rec := &GatewayRecord{}
+	ta.Equal(http.StatusNotFound, do("GET", "/v1/keys/foo", "", nil))
+
+	ta.Equal(http.StatusOK, do("PUT", "/v1/keys/foo", `{"value": 5}`, rec))
+	ta.Equal(&GatewayRecord{Key: "foo", Ver: 0, Value: 5}, rec)
+
+	ta.Equal(http.StatusOK, do("PUT", "/v1/keys/foo?prev_ver=0", `{"value": 6}`, rec))
+	ta.Equal(int64(1), rec.Ver)
+	ta.Equal(http.StatusConflict, do("PUT", "/v1/keys/foo?prev_ver=0", `{"value": 7}`, nil))
+
+	rec = &GatewayRecord{}
+	ta.Equal(http.StatusOK, do("GET", "/v1/keys/foo", "", rec))
+	ta.Equal(&GatewayRecord{Key: "foo", Ver: 1, Value: 6}, rec)
+
+	rec = &GatewayRecord{}
+	ta.Equal(http.StatusOK, do("GET", "/v1/keys/foo?ver=0", "", rec))
+	ta.Equal(int64(5), rec.Value)
+	ta.NotNil(rec.Bal)
+	ta.Equal(http.StatusNotFound, do("GET", "/v1/keys/foo?ver=9", "", nil))
+
+	ta.Equal(http.StatusOK, do("DELETE", "/v1/keys/foo", "", rec))
+	ta.Equal(int64(2), rec.Ver)
+	ta.Equal(http.StatusNotFound, do("DELETE", "/v1/keys/foo", "", nil))
+	ta.Equal(http.StatusNotFound, do("GET", "/v1/keys/foo", "", nil))
+
+	records := []*GatewayRecord{}
+	ta.Equal(http.StatusOK, do("GET", "/v1/keys/foo?history&from=1", "", &records))
+	ta.Equal(2, len(records))
+	ta.Equal(int64(6), records[0].Value)
+	ta.True(records[1].Deleted)
+
+	// keys with "/"
+	ta.Equal(http.StatusOK, do("PUT", "/v1/keys/a/b", `{"value": 1}`, nil))
+	ta.Equal(http.StatusOK, do("GET", "/v1/keys/a/b", "", rec))
+	ta.Equal("a/b", rec.Key)
+
+	errReply := &GatewayError{}
+	ta.Equal(http.StatusBadRequest, do("PUT", "/v1/keys/foo", `{"value": "x"}`, errReply))
+	ta.Contains(errReply.Error, "invalid body")
+	ta.Equal(http.StatusBadRequest, do("PUT", "/v1/keys/foo", `{"value": 1, "ttl": "-1s"}`, nil))
+	ta.Equal(http.StatusBadRequest, do("GET", "/v1/keys/foo?ver=-1", "", nil))
+	ta.Equal(http.StatusBadRequest, do("GET", "/v1/keys/", "", nil))
+	ta.Equal(http.StatusMethodNotAllowed, do("POST", "/v1/keys/foo", "", nil))
+	ta.Equal(http.StatusNotFound, do("GET", "/v2/keys/foo", "", nil))
+}
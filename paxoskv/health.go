@@ -0,0 +1,284 @@
+package paxoskv
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// Health reports whether an Acceptor is serving with the standard gRPC health
+// service.
+//
+// It is not serving until SetServing(true), e.g., while the storage is being
+// recovered, and after SetServing(false), e.g., while the server is shutting
+// down. Meanwhile, requests of other services fail with code Unavailable.
+type Health struct {
+	server  *health.Server
+	serving int32
+}
+
+// NewHealth creates a Health that is not serving.
+func NewHealth() *Health {
+	h := &Health{server: health.NewServer()}
+	h.server.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	return h
+}
+
+// ServerOptions returns the options for a grpc.Server to refuse requests while
+// not serving.
+func (h *Health) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			if err := h.check(info.FullMethod); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.ChainStreamInterceptor(func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := h.check(info.FullMethod); err != nil {
+				return err
+			}
+			return handler(srv, ss)
+		}),
+	}
+}
+
+// Register registers the health service on `s`.
+func (h *Health) Register(s *grpc.Server) {
+	healthpb.RegisterHealthServer(s, h.server)
+}
+
+// SetServing sets whether the Acceptor is serving.
+func (h *Health) SetServing(serving bool) {
+	if serving {
+		atomic.StoreInt32(&h.serving, 1)
+		h.server.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	} else {
+		atomic.StoreInt32(&h.serving, 0)
+		h.server.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	}
+}
+
+// check returns an error with code Unavailable if a method other than of the
+// health service is called while not serving.
+func (h *Health) check(fullMethod string) error {
+	if atomic.LoadInt32(&h.serving) == 1 || strings.HasPrefix(fullMethod, "/grpc.health.v1.Health/") {
+		return nil
+	}
+	return status.Error(codes.Unavailable, "acceptor is not serving")
+}
+
+// rpcTimeout is the timeout of an RPC to an Acceptor.
+const rpcTimeout = time.Second
+
+// healthInterval is how often the connection pool checks the health of an
+// Acceptor.
+var healthInterval = 500 * time.Millisecond
+
+// unreachableChecks is the number of failed health checks in a row after
+// which an Acceptor is regarded as unhealthy.
+const unreachableChecks = 2
+
+// health status of a pooled connection, by the last health check.
+const (
+	healthUnknown int32 = iota
+	healthServing
+	healthNotServing
+	healthUnreachable
+)
+
+// connPool keeps a connection to every Acceptor address a proposer sends
+// requests to, and checks the health of every Acceptor in the background.
+type connPool struct {
+	mu    sync.Mutex
+	conns map[string]*pooledConn
+}
+
+type pooledConn struct {
+	*grpc.ClientConn
+
+	health int32
+
+	// probed is when the last probe of an unreachable Acceptor ended, in unix
+	// nano. It is -1 while a probe is running.
+	probed int64
+
+	// kick triggers a health check at once.
+	kick chan struct{}
+}
+
+// pool is the connection pool shared by all proposers in a process.
+var pool = &connPool{conns: map[string]*pooledConn{}}
+
+// get returns the connection to `addr`, creating it if absent.
+func (p *connPool) get(addr string) (*pooledConn, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if c, found := p.conns[addr]; found {
+		return c, nil
+	}
+
+	conn, err := dialAcceptor(addr,
+		grpc.WithConnectParams(grpc.ConnectParams{
+			Backoff: backoff.Config{
+				BaseDelay:  100 * time.Millisecond,
+				Multiplier: 1.6,
+				Jitter:     0.2,
+				MaxDelay:   3 * time.Second,
+			},
+			MinConnectTimeout: time.Second,
+		}))
+	if err != nil {
+		return nil, err
+	}
+
+	c := &pooledConn{ClientConn: conn, kick: make(chan struct{}, 1)}
+	p.conns[addr] = c
+	go c.checkHealth()
+	return c, nil
+}
+
+// reset regards the Acceptor at `addr` as healthy again, e.g., after it is
+// started in this process.
+func (p *connPool) reset(addr string) {
+	p.mu.Lock()
+	c := p.conns[addr]
+	p.mu.Unlock()
+
+	if c != nil {
+		atomic.StoreInt32(&c.health, healthUnknown)
+		atomic.StoreInt64(&c.probed, 0)
+		c.ResetConnectBackoff()
+	}
+}
+
+// callAcceptor calls `fn` with a client of an Acceptor on its pooled
+// connection, and a context timing out in rpcTimeout.
+// It returns an error with code Unavailable, without calling `fn`, if the pool
+// finds the Acceptor unhealthy.
+func callAcceptor(aid int64, fn func(ctx context.Context, cli PaxosKVClient) error) error {
+
+	conn, err := pool.get(acceptorAddr(aid))
+	if err != nil {
+		return err
+	}
+	if !conn.healthy() {
+		return status.Error(codes.Unavailable, "unhealthy acceptor")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), rpcTimeout)
+	defer cancel()
+
+	return fn(ctx, NewPaxosKVClient(conn))
+}
+
+// healthy returns false if the Acceptor replies it is not serving to the last
+// health check, or if it is unreachable: an RPC to it fails, the connection is
+// in TRANSIENT_FAILURE at a failed check, or unreachableChecks checks in a row
+// fail.
+//
+// A not serving Acceptor is checked again at once, to find out soon that it is
+// back. An unreachable one is probed by the caller, at most once every
+// healthInterval, for at most reconnectTimeout.
+func (c *pooledConn) healthy() bool {
+	switch atomic.LoadInt32(&c.health) {
+	case healthNotServing:
+		select {
+		case c.kick <- struct{}{}:
+		default:
+		}
+		return false
+	case healthUnreachable:
+		return c.probe()
+	}
+	return true
+}
+
+// markUnhealthy marks the Acceptor unreachable after an RPC to it fails.
+func (c *pooledConn) markUnhealthy() {
+	atomic.StoreInt32(&c.health, healthUnreachable)
+}
+
+// probe reconnects to an unreachable Acceptor and checks its health, unless
+// another probe is running or ended in the last healthInterval.
+// It returns whether the Acceptor is back.
+func (c *pooledConn) probe() bool {
+	last := atomic.LoadInt64(&c.probed)
+	if last < 0 || time.Now().UnixNano()-last < int64(healthInterval) || !atomic.CompareAndSwapInt64(&c.probed, last, -1) {
+		return false
+	}
+	defer func() { atomic.StoreInt64(&c.probed, time.Now().UnixNano()) }()
+
+	c.ResetConnectBackoff()
+	ctx, cancel := context.WithTimeout(context.Background(), reconnectTimeout)
+	defer cancel()
+
+	h := c.check(ctx, grpc.WaitForReady(true))
+	if h == healthUnknown {
+		return false
+	}
+	atomic.StoreInt32(&c.health, h)
+	return h == healthServing
+}
+
+// check sends a health check, and returns the health status by it, or
+// healthUnknown if it fails. An Acceptor without the health service is
+// regarded as serving.
+func (c *pooledConn) check(ctx context.Context, opts ...grpc.CallOption) int32 {
+
+	reply, err := healthpb.NewHealthClient(c.ClientConn).Check(ctx, &healthpb.HealthCheckRequest{}, opts...)
+	switch {
+	case status.Code(err) == codes.Unimplemented:
+		return healthServing
+	case err != nil:
+		return healthUnknown
+	case reply.Status == healthpb.HealthCheckResponse_SERVING:
+		return healthServing
+	default:
+		return healthNotServing
+	}
+}
+
+// checkHealth checks the health of the Acceptor every healthInterval, or when
+// kicked.
+// A failed check keeps the last status, unless the connection is in
+// TRANSIENT_FAILURE or unreachableChecks checks in a row fail.
+func (c *pooledConn) checkHealth() {
+
+	failures := 0
+
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), rpcTimeout)
+		h := c.check(ctx)
+		cancel()
+
+		if h == healthUnknown {
+			failures++
+			h = atomic.LoadInt32(&c.health)
+			if failures >= unreachableChecks || c.GetState() == connectivity.TransientFailure {
+				h = healthUnreachable
+			}
+		} else {
+			failures = 0
+		}
+		atomic.StoreInt32(&c.health, h)
+
+		t := time.NewTimer(healthInterval)
+		select {
+		case <-t.C:
+		case <-c.kick:
+			t.Stop()
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package paxoskv
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// serveWithHealth starts an Acceptor that is not serving on a random port.
+func serveWithHealth(ta *require.Assertions) *AcceptorServer {
+
+	kvs, err := NewKVServer("")
+	ta.Nil(err)
+
+	a := NewAcceptorServer(kvs, "127.0.0.1:0")
+	ta.Nil(a.Start())
+	a.Health.SetServing(false)
+
+	return a
+}
+
+func TestHealth(t *testing.T) {
+
+	ta := require.New(t)
+
+	a := serveWithHealth(ta)
+	defer a.Stop()
+	h, addr := a.Health, a.Addr()
+
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	ta.Nil(err)
+	defer conn.Close()
+
+	hc := healthpb.NewHealthClient(conn)
+	cli := NewPaxosKVClient(conn)
+	ctx := context.Background()
+	p := &Proposer{Id: &PaxosInstanceId{Key: "foo"}, Bal: &BallotNum{N: 1}}
+
+	reply, err := hc.Check(ctx, &healthpb.HealthCheckRequest{})
+	ta.Nil(err)
+	ta.Equal(healthpb.HealthCheckResponse_NOT_SERVING, reply.Status)
+
+	_, err = cli.Prepare(ctx, p)
+	ta.Equal(codes.Unavailable, status.Code(err))
+
+	h.SetServing(true)
+
+	reply, err = hc.Check(ctx, &healthpb.HealthCheckRequest{})
+	ta.Nil(err)
+	ta.Equal(healthpb.HealthCheckResponse_SERVING, reply.Status)
+
+	_, err = cli.Prepare(ctx, p)
+	ta.Nil(err)
+
+	h.SetServing(false)
+
+	reply, err = hc.Check(ctx, &healthpb.HealthCheckRequest{})
+	ta.Nil(err)
+	ta.Equal(healthpb.HealthCheckResponse_NOT_SERVING, reply.Status)
+
+	_, err = cli.Prepare(ctx, p)
+	ta.Equal(codes.Unavailable, status.Code(err))
+}
+
+func TestConnPool_SkipNotServing(t *testing.T) {
+
+	ta := require.New(t)
+
+	servers, err := ServeAcceptors([]int64{0, 1})
+	ta.Nil(err)
+	defer func() {
+		for _, s := range servers {
+			s.Stop()
+		}
+	}()
+
+	a := serveWithHealth(ta)
+	defer a.Stop()
+	h, kvs, addr := a.Health, a.KVServer, a.Addr()
+
+	UseCluster(&Cluster{Addrs: map[int64]string{9: addr}})
+	defer UseCluster(nil)
+
+	acceptorIds := []int64{0, 1, 9}
+
+	conn, err := pool.get(addr)
+	ta.Nil(err)
+	ta.Eventually(func() bool { return !conn.healthy() }, 2*time.Second, 10*time.Millisecond)
+
+	prepares := rpcDuration.get("9", "Prepare")
+
+	p := &Proposer{Id: &PaxosInstanceId{Key: "foo", Ver: 0}, Bal: &BallotNum{N: 1, ProposerId: 1}}
+	v := p.RunPaxos(acceptorIds, &Value{Vi64: 5})
+	ta.Equal(int64(5), v.Vi64)
+	ta.Equal(prepares, rpcDuration.get("9", "Prepare"), "Acceptor-9 is skipped")
+
+	h.SetServing(true)
+	ta.Eventually(func() bool { return conn.healthy() }, 2*time.Second, 10*time.Millisecond)
+
+	p = &Proposer{Id: &PaxosInstanceId{Key: "foo", Ver: 1}, Bal: &BallotNum{N: 1, ProposerId: 1}}
+	v = p.RunPaxos(acceptorIds, &Value{Vi64: 6})
+	ta.Equal(int64(6), v.Vi64)
+	ta.Equal(prepares+1, rpcDuration.get("9", "Prepare"))
+
+	state, err := kvs.Inspect(context.Background(), &PaxosInstanceId{Key: "foo", Ver: 1})
+	ta.Nil(err)
+	ta.Equal(int64(6), state.Val.Vi64)
+}
+
+func TestConnPool_SkipUnreachable(t *testing.T) {
+
+	ta := require.New(t)
+
+	// Acceptor-30 is used by no other test, which would find it unhealthy.
+	acceptorIds := []int64{0, 1, 30}
+
+	servers, err := ServeAcceptors(acceptorIds)
+	ta.Nil(err)
+	defer func() {
+		for _, s := range servers {
+			s.Stop()
+		}
+	}()
+
+	c := &KVClient{AcceptorIds: acceptorIds, ProposerId: 2}
+	_, err = c.Set("foo", &Value{Vi64: 1})
+	ta.Nil(err)
+
+	servers[2].Stop()
+
+	// the first request finds Acceptor-30 down, the others skip it.
+	start := time.Now()
+	_, err = c.Set("foo", &Value{Vi64: 2})
+	ta.Nil(err)
+	ta.True(time.Since(start) < rpcTimeout, "a dead Acceptor does not hold up a phase")
+
+	conn, err := pool.get(acceptorAddr(30))
+	ta.Nil(err)
+	ta.False(conn.healthy())
+
+	prepares := rpcDuration.get("30", "Prepare")
+	_, err = c.Set("foo", &Value{Vi64: 3})
+	ta.Nil(err)
+	ta.Equal(prepares, rpcDuration.get("30", "Prepare"), "Acceptor-30 is skipped")
+}
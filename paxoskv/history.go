@@ -0,0 +1,59 @@
+package paxoskv
+
+import "golang.org/x/net/context"
+
+// History returns every chosen version of a key in [fromVer, toVer], in
+// version order, along with the ballot number at which each of them is chosen.
+// A negative `toVer` means up to the latest version.
+//
+// Tombstones are returned as they are. Versions written by transactions that
+// are not committed are skipped, since they are never visible, and so are the
+// versions compacted by GC after the key is deleted. Like Get, it does not
+// abort a transaction not yet decided. Thus applying
+// the returned records in order rebuilds the state of the key.
+//
+// A value may be chosen at more than one ballot number: proposers running
+// paxos on a version concurrently may all see it voted and choose it again,
+// each committing it with its own ballot. Thus the ballot number of a version
+// is one at which it is chosen, and may differ between calls.
+func (c *KVClient) History(key string, fromVer, toVer int64) ([]*Record, error) {
+
+	records := []*Record{}
+
+	if fromVer < 0 {
+		fromVer = 0
+	}
+
+	ctx := context.Background()
+
+	for ver := fromVer; toVer < 0 || ver <= toVer; ver++ {
+
+		v, bal, err := c.choose(ctx, key, ver, nil)
+		if err == InstanceCompacted {
+			// dropped by GC after the key is deleted.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if v == nil {
+			// versions are written one after another: there is no chosen
+			// version after the first absent one.
+			break
+		}
+
+		if v.TxnId != "" {
+			committed, err := c.txnSeenCommitted(ctx, v.TxnId)
+			if err != nil {
+				return nil, err
+			}
+			if !committed {
+				continue
+			}
+		}
+
+		records = append(records, &Record{Key: key, Ver: ver, Val: v, Bal: bal})
+	}
+
+	return records, nil
+}
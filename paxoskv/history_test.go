@@ -0,0 +1,90 @@
+package paxoskv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestKVClient_History(t *testing.T) {
+
+	ta := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+
+	servers, err := ServeAcceptors(acceptorIds)
+	ta.Nil(err)
+	defer func() {
+		for _, s := range servers {
+			s.Stop()
+		}
+	}()
+
+	c := &KVClient{AcceptorIds: acceptorIds, ProposerId: 2}
+
+	records, err := c.History("foo", 0, -1)
+	ta.Nil(err)
+	ta.Equal(0, len(records), "absent key")
+
+	_, err = c.Set("foo", &Value{Vi64: 5})
+	ta.Nil(err)
+	_, err = c.Set("foo", &Value{Vi64: 6})
+	ta.Nil(err)
+
+	// an intent of a transaction that never commits
+	_, err = c.setFrom(context.Background(), "foo", 2, &Value{Vi64: 7, TxnId: "t1"})
+	ta.Nil(err)
+
+	_, err = c.Delete("foo")
+	ta.Nil(err)
+
+	records, err = c.History("foo", 0, -1)
+	ta.Nil(err)
+
+	vers := []int64{}
+	for _, r := range records {
+		ta.Equal("foo", r.Key)
+		ta.NotNil(r.Bal)
+		vers = append(vers, r.Ver)
+	}
+	ta.Equal([]int64{0, 1, 3}, vers, "the aborted intent is skipped")
+	ta.Equal(int64(5), records[0].Val.Vi64)
+	ta.Equal(int64(6), records[1].Val.Vi64)
+	ta.True(records[2].Val.Deleted)
+
+	// without concurrent proposers, every Acceptor has committed a version at
+	// the same ballot, and reading again returns it.
+
+	other := &KVClient{AcceptorIds: acceptorIds, ProposerId: 3}
+	again, err := other.History("foo", 1, 3)
+	ta.Nil(err)
+	ta.Equal(2, len(again))
+	ta.True(proto.Equal(records[1], again[0]))
+	ta.True(proto.Equal(records[2], again[1]))
+}
+
+func TestKVServer_Commit(t *testing.T) {
+
+	ta := require.New(t)
+
+	kvs := KVServer{
+		Storage: map[string]Versions{},
+	}
+	id := &PaxosInstanceId{Key: "x", Ver: 0}
+
+	_, err := kvs.Commit(nil, &Proposer{Id: id, Bal: &BallotNum{N: 2}, Val: &Value{Vi64: 5}})
+	ta.Nil(err)
+
+	// an Accept with a higher ballot does not change a committed value.
+	_, err = kvs.Accept(nil, &Proposer{Id: id, Bal: &BallotNum{N: 3}, Val: &Value{Vi64: 5}})
+	ta.Nil(err)
+
+	reply, err := kvs.Prepare(nil, &Proposer{Id: id, Bal: &BallotNum{N: 4}})
+	ta.Nil(err)
+	ta.True(reply.Committed)
+	ta.Equal(int64(5), reply.Val.Vi64)
+	ta.Equal(int64(2), reply.VBal.N, "the ballot at which it is chosen")
+	ta.Equal(int64(3), reply.LastBal.N)
+}
@@ -3,20 +3,23 @@ package paxoskv
 import (
 	"errors"
 	"fmt"
-	"log"
-	"net"
+	"sort"
+	"strconv"
 	"sync"
 	"time"
 
-	"github.com/kr/pretty"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 var (
-	NotEnoughQuorum  = errors.New("not enough quorum")
-	AcceptorBasePort = int64(3333)
+	NotEnoughQuorum   = errors.New("not enough quorum")
+	ShardMoved        = errors.New("shard moved")
+	InstanceCompacted = errors.New("instance compacted")
+	StorageFailure    = errors.New("acceptor storage failure")
+	AcceptorBasePort  = int64(3333)
 )
 
 // GE compare two ballot number a, b and return whether a >= b in a bool
@@ -43,63 +46,191 @@ func (a *BallotNum) GE(b *BallotNum) bool {
 // it reads the specified version of a record by running a paxos without propose
 // any value: This func will finish paxos phase-2 to make it safe if a voted
 // value is found, otherwise, it just returns nil without running phase-2.
+//
+// After phase-2, it sends the established value to all Acceptors with a Commit
+// request. If a committed value is seen in phase-1, it is returned at once
+// without running phase-2.
+//
+// It returns nil if an Acceptor refuses the paxos instance, e.g., the key has
+// been moved to another acceptor group.
 func (p *Proposer) RunPaxos(acceptorIds []int64, val *Value) *Value {
+	v, _, _ := p.runPaxos(acceptorIds, val, defaultEnv())
+	return v
+}
+
+// runEnv is how a Proposer runs paxos: where it logs and traces, how it
+// picks the ballot N to retry with, and when it gives up.
+type runEnv struct {
+	// ctx is the parent of the RPCs. The Proposer stops retrying when it is
+	// done.
+	ctx    context.Context
+	logger Logger
+	tracer *Tracer
+	next   func(higher int64) (int64, error)
+}
+
+// defaultEnv returns the runEnv of the exported methods of Proposer: log to the
+// Logger set by SetLogger, trace with the Tracer set by SetTracer, and retry
+// with the ballot N next to the higher one.
+func defaultEnv() *runEnv {
+	return &runEnv{
+		ctx:    context.Background(),
+		logger: loggerOr(nil),
+		tracer: tracerOr(nil),
+		next: func(higher int64) (int64, error) {
+			return higher + 1, nil
+		},
+	}
+}
+
+// log logs an entry about this Proposer, with the instance id and the ballot.
+func (p *Proposer) log(lg Logger, level Level, msg string, fields ...Field) {
+	lg.Log(level, msg, append([]Field{F("key", p.Id.Key), F("ver", p.Id.Ver), F("bal", p.Bal)}, fields...)...)
+}
 
+// runPaxos is the same as RunPaxos except that it also returns the ballot
+// number at which the returned value is chosen, and the error if an Acceptor
+// refuses the paxos instance.
+//
+// When a higher ballot is seen, it retries with the ballot N returned by
+// env.next, which a proposer uses to never reuse a ballot. Once env.ctx is
+// done, it returns the error of env.ctx instead of retrying.
+func (p *Proposer) runPaxos(acceptorIds []int64, val *Value, env *runEnv) (*Value, *BallotNum, error) {
+
+	lg := env.logger
 	quorum := len(acceptorIds)/2 + 1
 
+	ctx, span := env.tracer.Start(env.ctx, "RunPaxos")
+	span.SetAttr("key", p.Id.Key)
+	span.SetAttr("ver", p.Id.Ver)
+	defer span.End()
+
+	retries := 0
+	defer func() {
+		runPaxosRetries.Observe(float64(retries))
+		span.SetAttr("retries", retries)
+	}()
+
 	for {
+		if err := env.ctx.Err(); err != nil {
+			p.log(lg, LevelDebug, "Proposer: give up", F("err", err))
+			return nil, nil, err
+		}
+
 		p.Val = nil
 
-		maxVotedVal, higherBal, err := p.Phase1(acceptorIds, quorum)
+		maxVoted, higherBal, err := p.phase1(ctx, acceptorIds, quorum, env)
 		if err != nil {
-			pretty.Logf("Proposer: fail to run phase-1: highest ballot: %v, increment ballot and retry", higherBal)
-			p.Bal.N = higherBal.N + 1
+			if err != NotEnoughQuorum {
+				p.log(lg, LevelWarn, "Proposer: phase-1 refused", F("err", err))
+				return nil, nil, err
+			}
+			p.log(lg, LevelDebug, "Proposer: fail to run phase-1, increment ballot and retry", F("higher", higherBal))
+			if p.Bal.N, err = env.next(higherBal.N); err != nil {
+				return nil, nil, err
+			}
+			retries++
 			continue
 		}
 
-		if maxVotedVal == nil {
-			pretty.Logf("Proposer: no voted value seen, propose my value: %v", val)
+		if maxVoted.Committed {
+			p.log(lg, LevelDebug, "Proposer: value is already committed", F("val", maxVoted.Val))
+			return maxVoted.Val, maxVoted.VBal, nil
+		}
+
+		if maxVoted.Val == nil {
+			p.log(lg, LevelDebug, "Proposer: no voted value seen, propose my value", F("val", val))
 		} else {
-			val = maxVotedVal
+			val = maxVoted.Val
 		}
 
 		if val == nil {
-			pretty.Logf("Proposer: no value to propose in phase-2, quit")
-			return nil
+			p.log(lg, LevelDebug, "Proposer: no value to propose in phase-2, quit")
+			return nil, nil, nil
 		}
 
 		p.Val = val
-		pretty.Logf("Proposer: proposer chose value to propose: %s", p.Val)
+		p.log(lg, LevelDebug, "Proposer: proposer chose value to propose", F("val", p.Val))
 
-		higherBal, err = p.Phase2(acceptorIds, quorum)
+		higherBal, err = p.phase2(ctx, acceptorIds, quorum, env)
 		if err != nil {
-			pretty.Logf("Proposer: fail to run phase-2: highest ballot: %v, increment ballot and retry", higherBal)
-			p.Bal.N = higherBal.N + 1
+			if err != NotEnoughQuorum {
+				p.log(lg, LevelWarn, "Proposer: phase-2 refused", F("err", err))
+				return nil, nil, err
+			}
+			p.log(lg, LevelDebug, "Proposer: fail to run phase-2, increment ballot and retry", F("higher", higherBal))
+			if p.Bal.N, err = env.next(higherBal.N); err != nil {
+				return nil, nil, err
+			}
+			retries++
 			continue
 		}
 
-		pretty.Logf("Proposer: value is voted by a quorum and has been safe: %v", maxVotedVal)
-		return p.Val
+		p.log(lg, LevelDebug, "Proposer: value is voted by a quorum and has been safe", F("val", p.Val))
+
+		// Committing is only a hint for Acceptors, it does not matter if
+		// some of them fail.
+		p.rpcToAll(ctx, acceptorIds, "Commit", env)
+
+		return p.Val, p.Bal, nil
 	}
 }
 
 // Phase1 run paxos phase-1 on the specified acceptorIds.
 // If a higher ballot number is seen and phase-1 failed to constitute a quorum,
 // one of the higher ballot number and a NotEnoughQuorum is returned.
+// If an Acceptor refuses the instance, the error it replies is returned, such
+// as ShardMoved.
+// If too many Acceptors fail to store their state to constitute a quorum,
+// StorageFailure is returned.
 func (p *Proposer) Phase1(acceptorIds []int64, quorum int) (*Value, *BallotNum, error) {
+	maxVoted, higherBal, err := p.phase1(context.Background(), acceptorIds, quorum, defaultEnv())
+	if err != nil {
+		return nil, higherBal, err
+	}
+	return maxVoted.Val, nil, nil
+}
 
-	replies := p.rpcToAll(acceptorIds, "Prepare")
+// phase1 is the same as Phase1 except that it returns the reply with the
+// highest VBal, or a committed reply if there is one. It runs in `env`, with
+// the span in `ctx` as the parent of its span.
+func (p *Proposer) phase1(ctx context.Context, acceptorIds []int64, quorum int, env *runEnv) (*Acceptor, *BallotNum, error) {
+
+	lg := env.logger
+	phaseAttempts.Inc("1")
+
+	ctx, span := env.tracer.Start(ctx, "Phase1")
+	span.SetAttr("bal", p.Bal)
+	defer span.End()
+
+	replies, err := p.rpcToAll(ctx, acceptorIds, "Prepare", env)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	ok := 0
-	higherBal := *p.Bal
+	failed := 0
+	higherBal := &BallotNum{N: p.Bal.N, ProposerId: p.Bal.ProposerId}
 	maxVoted := &Acceptor{VBal: &BallotNum{}}
 
 	for _, r := range replies {
 
-		pretty.Logf("Proposer: handling Prepare reply: %s", r)
-		if !p.Bal.GE(r.LastBal) {
-			if r.LastBal.GE(&higherBal) {
-				higherBal = *r.LastBal
+		p.log(lg, LevelDebug, "Proposer: handling Prepare reply", F("reply", r))
+
+		if r.Status == AcceptorStatus_StorageError {
+			failed++
+			continue
+		}
+
+		// a committed value is chosen, no matter what ballot number it is.
+		if r.Committed {
+			return r, nil, nil
+		}
+
+		if r.Status == AcceptorStatus_RejectedHigherBallot {
+			higherBallotRejections.Inc("1")
+			if r.LastBal.GE(higherBal) {
+				higherBal = r.LastBal
 			}
 			continue
 		}
@@ -111,28 +242,57 @@ func (p *Proposer) Phase1(acceptorIds []int64, quorum int) (*Value, *BallotNum,
 
 		ok += 1
 		if ok == quorum {
-			return maxVoted.Val, nil, nil
+			return maxVoted, nil, nil
 		}
 	}
 
-	return nil, &higherBal, NotEnoughQuorum
+	if len(acceptorIds)-failed < quorum {
+		return nil, nil, StorageFailure
+	}
+	return nil, higherBal, NotEnoughQuorum
 
 }
 
 // Phase2 run paxos phase-2 on the specified acceptorIds.
 // If a higher ballot number is seen and phase-2 failed to constitute a quorum,
 // one of the higher ballot number and a NotEnoughQuorum is returned.
+// If an Acceptor refuses the instance, the error it replies is returned, such
+// as ShardMoved.
+// If too many Acceptors fail to store their state to constitute a quorum,
+// StorageFailure is returned.
 func (p *Proposer) Phase2(acceptorIds []int64, quorum int) (*BallotNum, error) {
+	return p.phase2(context.Background(), acceptorIds, quorum, defaultEnv())
+}
+
+// phase2 is the same as Phase2 except that it runs in `env`, with the span in
+// `ctx` as the parent of its span.
+func (p *Proposer) phase2(ctx context.Context, acceptorIds []int64, quorum int, env *runEnv) (*BallotNum, error) {
+
+	lg := env.logger
+	phaseAttempts.Inc("2")
 
-	replies := p.rpcToAll(acceptorIds, "Accept")
+	ctx, span := env.tracer.Start(ctx, "Phase2")
+	span.SetAttr("bal", p.Bal)
+	defer span.End()
+
+	replies, err := p.rpcToAll(ctx, acceptorIds, "Accept", env)
+	if err != nil {
+		return nil, err
+	}
 
 	ok := 0
-	higherBal := *p.Bal
+	failed := 0
+	higherBal := &BallotNum{N: p.Bal.N, ProposerId: p.Bal.ProposerId}
 	for _, r := range replies {
-		pretty.Logf("Proposer: handling Accept reply: %s", r)
-		if !p.Bal.GE(r.LastBal) {
-			if r.LastBal.GE(&higherBal) {
-				higherBal = *r.LastBal
+		p.log(lg, LevelDebug, "Proposer: handling Accept reply", F("reply", r))
+		if r.Status == AcceptorStatus_StorageError {
+			failed++
+			continue
+		}
+		if r.Status == AcceptorStatus_RejectedHigherBallot {
+			higherBallotRejections.Inc("2")
+			if r.LastBal.GE(higherBal) {
+				higherBal = r.LastBal
 			}
 			continue
 		}
@@ -142,47 +302,133 @@ func (p *Proposer) Phase2(acceptorIds []int64, quorum int) (*BallotNum, error) {
 		}
 	}
 
-	return &higherBal, NotEnoughQuorum
+	if len(acceptorIds)-failed < quorum {
+		return nil, StorageFailure
+	}
+	return higherBal, NotEnoughQuorum
 
 }
 
-// rpcToAll send Prepare or Accept RPC to the specified Acceptors.
-func (p *Proposer) rpcToAll(acceptorIds []int64, action string) []*Acceptor {
+// rpcToAll send Prepare, Accept or Commit RPC to the specified Acceptors,
+// skipping those found unhealthy by the connection pool.
+// It returns the replies, except those with status NotMember or
+// InstanceCompacted: it returns ShardMoved or InstanceCompacted for them.
+//
+// Every RPC has a span, which is passed to the Acceptor in gRPC metadata.
+func (p *Proposer) rpcToAll(ctx context.Context, acceptorIds []int64, action string, env *runEnv) ([]*Acceptor, error) {
+
+	lg := env.logger
 
 	replies := []*Acceptor{}
+	var refused error
 
 	for _, aid := range acceptorIds {
-		var err error
-		address := fmt.Sprintf("127.0.0.1:%d", AcceptorBasePort+int64(aid))
-		// Set up a connection to the server.
-		conn, err := grpc.Dial(address, grpc.WithInsecure())
+		conn, err := pool.get(acceptorAddr(aid))
 		if err != nil {
-			log.Fatalf("did not connect: %v", err)
+			p.log(lg, LevelWarn, "Proposer: fail to connect", F("acceptor", aid), F("err", err))
+			continue
 		}
 
-		defer conn.Close()
-		c := NewPaxosKVClient(conn)
+		if !conn.healthy() {
+			p.log(lg, LevelDebug, "Proposer: skip unhealthy Acceptor", F("acceptor", aid))
+			continue
+		}
 
-		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
-		defer cancel()
+		rpcCtx, span := env.tracer.Start(ctx, action)
+		span.SetAttr("acceptor", aid)
 
-		var reply *Acceptor
-		if action == "Prepare" {
-			reply, err = c.Prepare(ctx, p)
-		} else if action == "Accept" {
-			reply, err = c.Accept(ctx, p)
-		}
+		start := time.Now()
+		reply, err := p.rpcTo(injectTrace(rpcCtx), conn, action)
+		aidLabel := strconv.FormatInt(aid, 10)
+		rpcDuration.Observe(time.Since(start).Seconds(), aidLabel, action)
 		if err != nil {
-			log.Printf("Proposer: %s failure from Acceptor-%d: %v", action, aid, err)
+			rpcFailures.Inc(aidLabel, action)
+			p.log(lg, LevelWarn, "Proposer: "+action+" failure", F("acceptor", aid), F("err", err))
+			span.SetAttr("error", err)
 		}
-		log.Printf("Proposer: recv %s reply from: Acceptor-%d: %v", action, aid, reply)
+		if reply != nil {
+			span.SetAttr("status", reply.Status)
+		}
+		span.End()
+		p.log(lg, LevelDebug, "Proposer: recv "+action+" reply", F("acceptor", aid), F("reply", reply))
 
 		// hear may be nil if rpc inner err
-		if reply != nil {
+		if reply == nil {
+			continue
+		}
+
+		switch reply.Status {
+		case AcceptorStatus_NotMember:
+			refused = ShardMoved
+		case AcceptorStatus_InstanceCompacted:
+			refused = InstanceCompacted
+		case AcceptorStatus_StorageError:
+			rpcFailures.Inc(aidLabel, action)
+			p.log(lg, LevelWarn, "Proposer: Acceptor fails to store "+action, F("acceptor", aid))
+			replies = append(replies, reply)
+		default:
 			replies = append(replies, reply)
 		}
 	}
-	return replies
+	return replies, refused
+}
+
+// reconnectTimeout is how long rpcTo waits for a pooled connection to
+// reconnect, well below rpcTimeout: a dead Acceptor must not hold up a phase.
+const reconnectTimeout = 100 * time.Millisecond
+
+// rpcTo sends a Prepare, Accept or Commit RPC on a pooled connection.
+//
+// A pooled connection may be to an Acceptor that has restarted, and be waiting
+// to reconnect. It reconnects at once and resends, waiting at most
+// reconnectTimeout: handling a request twice is the same as once. If it still
+// fails, the connection is marked unhealthy, to be skipped until a health check
+// finds the Acceptor back.
+func (p *Proposer) rpcTo(ctx context.Context, conn *pooledConn, action string) (*Acceptor, error) {
+
+	c := NewPaxosKVClient(conn)
+	call := func(ctx context.Context, opts ...grpc.CallOption) (*Acceptor, error) {
+		ctx, cancel := context.WithTimeout(ctx, rpcTimeout)
+		defer cancel()
+
+		switch action {
+		case "Prepare":
+			return c.Prepare(ctx, p, opts...)
+		case "Accept":
+			return c.Accept(ctx, p, opts...)
+		default:
+			return c.Commit(ctx, p, opts...)
+		}
+	}
+
+	reply, err := call(ctx)
+	if status.Code(err) != codes.Unavailable {
+		return reply, err
+	}
+
+	conn.ResetConnectBackoff()
+	retryCtx, cancel := context.WithTimeout(ctx, reconnectTimeout)
+	defer cancel()
+
+	reply, err = call(retryCtx, grpc.WaitForReady(true))
+	if code := status.Code(err); code == codes.Unavailable || code == codes.DeadlineExceeded {
+		conn.markUnhealthy()
+	}
+	return reply, err
+}
+
+// acceptorAddr returns the address to connect to an Acceptor, from the Cluster
+// set by UseCluster, or by AcceptorBasePort.
+func acceptorAddr(aid int64) string {
+	clusterMu.RLock()
+	defer clusterMu.RUnlock()
+
+	if cluster != nil {
+		if addr, found := cluster.Addrs[aid]; found {
+			return addr
+		}
+	}
+	return fmt.Sprintf("127.0.0.1:%d", AcceptorBasePort+int64(aid))
 }
 
 // Version defines one modification of a key-value record.
@@ -201,18 +447,72 @@ type KVServer struct {
 	UnimplementedPaxosKVServer
 	mu      sync.Mutex
 	Storage map[string]Versions
+
+	// Id is the id of this Acceptor, attached to every log entry of it.
+	Id int64
+
+	// Logger receives the log entries of this Acceptor. If it is nil, the
+	// Logger set by SetLogger is used.
+	Logger Logger
+
+	// Tracer traces the requests to this Acceptor, as children of the spans
+	// of the proposers. If it is nil, the Tracer set by SetTracer is used.
+	Tracer *Tracer
+
+	// ACL, if not nil, is the permissions on keys required by requests:
+	// write for Prepare, Accept and Commit, and read for Inspect, Keys and
+	// Watch.
+	ACL *ACL
+
+	// keys are the keys in Storage, in order.
+	keys []string
+
+	// tombstoned records the keys GC found tombstoned, and at which version.
+	tombstoned map[string]int64
+
+	// compacted is the highest version GC dropped of every key. Versions up
+	// to it are refused with InstanceCompacted.
+	compacted map[string]int64
+
+	// watchMu protects watchers.
+	watchMu  sync.Mutex
+	watchers map[*watcher]bool
+
+	// fences are the key ranges this Acceptor refuses to serve.
+	fences []*KeyRange
+
+	// wal is the write-ahead log, or nil if the state is only in memory.
+	wal *wal
 }
 
-func (s *KVServer) getLockedVersion(id *PaxosInstanceId) *Version {
+// log logs an entry of this Acceptor, with the Acceptor id.
+func (s *KVServer) log(level Level, msg string, fields ...Field) {
+	loggerOr(s.Logger).Log(level, msg, append([]Field{F("acceptor", s.Id)}, fields...)...)
+}
+
+// getLockedVersion returns the Version of a paxos instance with its lock held.
+// It returns an error with code FailedPrecondition if the key is fenced, or
+// with code OutOfRange if the version is compacted.
+func (s *KVServer) getLockedVersion(id *PaxosInstanceId) (*Version, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	key := id.Key
 	ver := id.Ver
+
+	if s.fenced(key) {
+		s.log(LevelWarn, "Acceptor: refuse fenced key", F("key", key))
+		return nil, status.Errorf(codes.FailedPrecondition, "%v: %s", ShardMoved, key)
+	}
+	if floor, found := s.compacted[key]; found && ver <= floor {
+		s.log(LevelDebug, "Acceptor: refuse compacted version", F("key", key), F("ver", ver), F("compacted", floor))
+		return nil, status.Errorf(codes.OutOfRange, "%v: %s₍%d₎", InstanceCompacted, key, ver)
+	}
 	rec, found := s.Storage[key]
 	if !found {
 		rec = Versions{}
 		s.Storage[key] = rec
+		s.addKey(key)
 	}
 
 	v, found := rec[ver]
@@ -227,10 +527,94 @@ func (s *KVServer) getLockedVersion(id *PaxosInstanceId) *Version {
 		v = rec[ver]
 	}
 
-	pretty.Logf("Acceptor: getLockedVersion: %s", v)
 	v.mu.Lock()
+	s.log(LevelDebug, "Acceptor: getLockedVersion", F("key", key), F("ver", ver), F("state", &v.acceptor))
 
-	return v
+	return v, nil
+}
+
+// GC drops keys whose latest version holds a committed tombstone from Storage
+// and returns the dropped keys.
+//
+// A key is dropped only if the previous GC call has already seen it
+// tombstoned at the same version. Thus an operation still working on a newer
+// version of the key gets at least one GC interval to finish, before this
+// acceptor forgets the key.
+//
+// Acceptors drop a key independently, thus the dropped versions must not be
+// run again: an Acceptor that forgot a vote would make a second value chosen.
+// The highest dropped version is kept, and every version up to it is refused
+// with InstanceCompacted. A client reads a compacted version as a tombstone,
+// and a later Set writes the version after it.
+func (s *KVServer) GC() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prev := s.tombstoned
+	s.tombstoned = map[string]int64{}
+
+	dropped := []string{}
+	for key, rec := range s.Storage {
+		ver, v := rec.latest()
+		if v == nil {
+			continue
+		}
+
+		v.mu.Lock()
+		// a tombstone only voted may not be chosen, and one written by a
+		// transaction may be aborted.
+		val := v.acceptor.Val
+		deleted := v.acceptor.Committed && val != nil && val.Deleted && val.TxnId == ""
+		v.mu.Unlock()
+
+		if !deleted {
+			continue
+		}
+
+		if pv, found := prev[key]; found && pv == ver {
+			e := &LogEntry{Compact: &PaxosInstanceId{Key: key, Ver: ver}}
+			if err := s.writeLog(e); err != nil {
+				continue
+			}
+			s.apply(e)
+			dropped = append(dropped, key)
+			s.log(LevelInfo, "Acceptor: GC dropped key", F("key", key), F("ver", ver))
+		} else {
+			s.tombstoned[key] = ver
+		}
+	}
+
+	return dropped
+}
+
+// addKey adds a new key into the ordered key list.
+func (s *KVServer) addKey(key string) {
+	i := sort.SearchStrings(s.keys, key)
+	s.keys = append(s.keys, "")
+	copy(s.keys[i+1:], s.keys[i:])
+	s.keys[i] = key
+}
+
+// removeKey removes a key from the ordered key list.
+func (s *KVServer) removeKey(key string) {
+	i := sort.SearchStrings(s.keys, key)
+	if i < len(s.keys) && s.keys[i] == key {
+		s.keys = append(s.keys[:i], s.keys[i+1:]...)
+	}
+}
+
+// latest returns the highest version and the Version of it.
+// It returns -1 and nil if there is no version at all.
+func (vs Versions) latest() (int64, *Version) {
+	maxVer := int64(-1)
+	var latest *Version
+	for ver, v := range vs {
+		if ver > maxVer {
+			maxVer = ver
+			latest = v
+		}
+	}
+	return maxVer, latest
 }
 
 // Prepare handles Prepare request.
@@ -239,17 +623,46 @@ func (s *KVServer) getLockedVersion(id *PaxosInstanceId) *Version {
 // Acceptor itself as reply data structure.
 func (s *KVServer) Prepare(c context.Context, r *Proposer) (*Acceptor, error) {
 
-	pretty.Logf("Acceptor: recv Prepare-request: %v", r)
+	s.log(LevelDebug, "Acceptor: recv Prepare-request", F("key", r.GetId().GetKey()), F("ver", r.GetId().GetVer()), F("bal", r.GetBal()))
 
-	v := s.getLockedVersion(r.Id)
+	if err := checkProposer(r); err != nil {
+		return nil, err
+	}
+	if err := s.ACL.check(c, r.Id.GetKey(), PermWrite); err != nil {
+		return nil, err
+	}
+
+	span := s.startSpan(c, "Acceptor.Prepare", r)
+	defer span.End()
+
+	v, err := s.getLockedVersion(r.Id)
+	if err != nil {
+		return refused(err)
+	}
 	defer v.mu.Unlock()
-	reply := v.acceptor
 
-	if r.Bal.GE(v.acceptor.LastBal) {
-		v.acceptor.LastBal = r.Bal
+	// copy the fields, not the struct: a generated message must not be
+	// copied by value.
+	reply := &Acceptor{
+		LastBal:   v.acceptor.LastBal,
+		Val:       v.acceptor.Val,
+		VBal:      v.acceptor.VBal,
+		Committed: v.acceptor.Committed,
 	}
 
-	return &reply, nil
+	if !r.Bal.GE(v.acceptor.LastBal) {
+		reply.Status = AcceptorStatus_RejectedHigherBallot
+		return reply, nil
+	}
+
+	next := v.state()
+	next.LastBal = r.Bal
+	if err := s.persist(r.Id, next); err != nil {
+		return s.storageError(r.Id, err), nil
+	}
+	v.set(next)
+
+	return reply, nil
 }
 
 // Accept handles Accept request.
@@ -257,51 +670,271 @@ func (s *KVServer) Prepare(c context.Context, r *Proposer) (*Acceptor, error) {
 // Acceptor as reply data structure.
 func (s *KVServer) Accept(c context.Context, r *Proposer) (*Acceptor, error) {
 
-	pretty.Logf("Acceptor: recv Accept-request: %v", r)
+	s.log(LevelDebug, "Acceptor: recv Accept-request", F("key", r.GetId().GetKey()), F("ver", r.GetId().GetVer()), F("bal", r.GetBal()), F("val", r.GetVal()))
 
-	v := s.getLockedVersion(r.Id)
+	if err := checkAccept(r); err != nil {
+		return nil, err
+	}
+	if err := s.ACL.check(c, r.Id.GetKey(), PermWrite); err != nil {
+		return nil, err
+	}
+
+	span := s.startSpan(c, "Acceptor.Accept", r)
+	defer span.End()
+
+	v, err := s.getLockedVersion(r.Id)
+	if err != nil {
+		return refused(err)
+	}
 	defer v.mu.Unlock()
 
 	// a := &X{}
 	// `b := &*a` does not deref the reference, b and a are the same pointer.
-	d := *v.acceptor.LastBal
 	reply := Acceptor{
-		LastBal: &d,
+		LastBal: &BallotNum{
+			N:          v.acceptor.LastBal.N,
+			ProposerId: v.acceptor.LastBal.ProposerId,
+		},
 	}
 
 	// article say acceptor's LastBal equal proposer's Bal will accept it
 	// but if greater, point that a large proposer's Bal has been through phrase1 with most acceptor, the same accept it
-	if r.Bal.GE(v.acceptor.LastBal) {
-		v.acceptor.LastBal = r.Bal
-		v.acceptor.Val = r.Val
-		v.acceptor.VBal = r.Bal
+	if !r.Bal.GE(v.acceptor.LastBal) {
+		reply.Status = AcceptorStatus_RejectedHigherBallot
+		return &reply, nil
+	}
+
+	next := v.state()
+	next.LastBal = r.Bal
+	// A committed value is chosen thus any proposer proposes the same
+	// value. Keep VBal to be the ballot at which it is chosen.
+	if !next.Committed {
+		next.Val = r.Val
+		next.VBal = r.Bal
+	}
+	if err := s.persist(r.Id, next); err != nil {
+		return s.storageError(r.Id, err), nil
+	}
+	v.set(next)
+
+	return &reply, nil
+}
+
+// Commit handles Commit request.
+// The value in a Commit request is chosen at the ballot number in it.
+// The Acceptor stores it and never changes it.
+func (s *KVServer) Commit(c context.Context, r *Proposer) (*Acceptor, error) {
+
+	s.log(LevelDebug, "Acceptor: recv Commit-request", F("key", r.GetId().GetKey()), F("ver", r.GetId().GetVer()), F("bal", r.GetBal()), F("val", r.GetVal()))
+
+	if err := checkCommit(r); err != nil {
+		return nil, err
+	}
+	if err := s.ACL.check(c, r.Id.GetKey(), PermWrite); err != nil {
+		return nil, err
+	}
+
+	span := s.startSpan(c, "Acceptor.Commit", r)
+	defer span.End()
+
+	v, err := s.getLockedVersion(r.Id)
+	if err != nil {
+		return refused(err)
+	}
+
+	next := v.state()
+	learnt := !next.Committed
+	if learnt {
+		next.Val = r.Val
+		next.VBal = r.Bal
+		next.Committed = true
+	}
+
+	if r.Bal.GE(next.LastBal) {
+		next.LastBal = r.Bal
+	}
+
+	if err := s.persist(r.Id, next); err != nil {
+		v.mu.Unlock()
+		return s.storageError(r.Id, err), nil
+	}
+	v.set(next)
+
+	reply := Acceptor{
+		LastBal: &BallotNum{
+			N:          v.acceptor.LastBal.N,
+			ProposerId: v.acceptor.LastBal.ProposerId,
+		},
+	}
+
+	v.mu.Unlock()
+
+	// notify without holding a Version lock, which must be acquired after
+	// s.mu.
+	if learnt {
+		s.notify(&Record{Key: r.Id.Key, Ver: r.Id.Ver, Val: r.Val, Bal: r.Bal})
 	}
 
 	return &reply, nil
 }
 
-// ServeAcceptors starts a grpc server for every acceptor.
-func ServeAcceptors(acceptorIds []int64) []*grpc.Server {
+// state returns a copy of the state of the instance, to change and persist
+// before changing the instance. It must be called with mu held.
+func (v *Version) state() *Acceptor {
+	return &Acceptor{
+		LastBal:   v.acceptor.LastBal,
+		Val:       v.acceptor.Val,
+		VBal:      v.acceptor.VBal,
+		Committed: v.acceptor.Committed,
+	}
+}
+
+// set replaces the state of the instance with `a`. It must be called with mu
+// held.
+func (v *Version) set(a *Acceptor) {
+	v.acceptor.LastBal = a.LastBal
+	v.acceptor.Val = a.Val
+	v.acceptor.VBal = a.VBal
+	v.acceptor.Committed = a.Committed
+}
 
-	servers := []*grpc.Server{}
+// refused returns the reply to a request on an instance getLockedVersion
+// refuses: NotMember if the key is fenced, InstanceCompacted if the version is
+// compacted.
+func refused(err error) (*Acceptor, error) {
+	switch status.Code(err) {
+	case codes.FailedPrecondition:
+		return &Acceptor{Status: AcceptorStatus_NotMember}, nil
+	case codes.OutOfRange:
+		return &Acceptor{Status: AcceptorStatus_InstanceCompacted}, nil
+	}
+	return nil, err
+}
+
+// storageError reports a failure to persist the state of an instance, and
+// returns the reply of it. The instance is not changed.
+func (s *KVServer) storageError(id *PaxosInstanceId, err error) *Acceptor {
+	s.log(LevelError, "Acceptor: fail to persist", F("key", id.Key), F("ver", id.Ver), F("err", err))
+	return &Acceptor{Status: AcceptorStatus_StorageError}
+}
+
+// Keys handles Keys request.
+// It lists keys in [r.Start, r.End) in order, no matter whether a value is
+// chosen for any version of them. With an ACL, only the keys readable by the
+// requester are listed.
+func (s *KVServer) Keys(c context.Context, r *KeyRange) (*KeyList, error) {
+
+	if s.ACL == nil {
+		return s.keysIn(r), nil
+	}
+
+	// r.Limit is applied to the readable keys.
+	reply := s.keysIn(&KeyRange{Start: r.Start, End: r.End})
+	keys, err := s.ACL.filter(c, reply.Keys)
+	if err != nil {
+		return nil, err
+	}
+	if r.Limit > 0 && int64(len(keys)) > r.Limit {
+		keys = keys[:r.Limit]
+	}
+	return &KeyList{Keys: keys}, nil
+}
+
+// LatestChosen handles LatestChosen request.
+// It replies the highest version of a key committed or compacted on this
+// Acceptor, or -1. It is only a hint to start reading the key from: a version
+// chosen without this Acceptor learning it is not seen.
+func (s *KVServer) LatestChosen(c context.Context, id *PaxosInstanceId) (*PaxosInstanceId, error) {
+
+	if err := checkInstanceId(id); err != nil {
+		return nil, err
+	}
+	if err := s.ACL.check(c, id.Key, PermRead); err != nil {
+		return nil, err
+	}
+	return &PaxosInstanceId{Key: id.Key, Ver: s.latestChosen(id.Key)}, nil
+}
+
+// latestChosen returns the highest version of a key committed or compacted on
+// this Acceptor, or -1.
+func (s *KVServer) latestChosen(key string) int64 {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	latest := int64(-1)
+	if floor, found := s.compacted[key]; found {
+		latest = floor
+	}
+	for ver, v := range s.Storage[key] {
+		if ver <= latest {
+			continue
+		}
+		v.mu.Lock()
+		if v.acceptor.Committed {
+			latest = ver
+		}
+		v.mu.Unlock()
+	}
+	return latest
+}
+
+// keysIn lists keys in [r.Start, r.End) in order, no matter whether a value is
+// chosen for any version of them.
+func (s *KVServer) keysIn(r *KeyRange) *KeyList {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reply := &KeyList{}
+
+	i := sort.SearchStrings(s.keys, r.Start)
+	for ; i < len(s.keys); i++ {
+		key := s.keys[i]
+		if r.End != "" && key >= r.End {
+			break
+		}
+		if r.Limit > 0 && int64(len(reply.Keys)) == r.Limit {
+			break
+		}
+		reply.Keys = append(reply.Keys, key)
+	}
+
+	return reply
+}
+
+// ServeAcceptors starts an AcceptorServer with in-memory storage for every
+// acceptor, on port AcceptorBasePort+id. If any fails to start, the started
+// ones are stopped.
+func ServeAcceptors(acceptorIds []int64) ([]*AcceptorServer, error) {
+
+	servers := []*AcceptorServer{}
 
 	for _, aid := range acceptorIds {
 		addr := fmt.Sprintf(":%d", AcceptorBasePort+int64(aid))
 
-		lis, err := net.Listen("tcp", addr)
-		if err != nil {
-			log.Fatalf("listen: %s %v", addr, err)
+		kvs, _ := NewKVServer("")
+		kvs.Id = aid
+
+		var opts []grpc.ServerOption
+		if t := currentTLS(); t != nil {
+			opts = append(opts, t.ServerOption())
 		}
 
-		s := grpc.NewServer()
-		RegisterPaxosKVServer(s, &KVServer{
-			Storage: map[string]Versions{},
-		})
-		reflection.Register(s)
-		pretty.Logf("Acceptor-%d serving on %s ...", aid, addr)
+		s := NewAcceptorServer(kvs, addr, opts...)
+		RegisterAdminServer(s.Server, NewAdminService(kvs, ""))
+		if err := s.Start(); err != nil {
+			for _, started := range servers {
+				started.Stop()
+			}
+			return nil, err
+		}
 		servers = append(servers, s)
-		go s.Serve(lis)
+
+		// a connection to the Acceptor that was stopped before needs no
+		// health check to be used.
+		pool.reset(acceptorAddr(aid))
 	}
 
-	return servers
+	return servers, nil
 }
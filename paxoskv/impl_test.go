@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
 )
 
 func TestAcceptor_Accept_deref_LastBal(t *testing.T) {
@@ -20,6 +21,7 @@ func TestAcceptor_Accept_deref_LastBal(t *testing.T) {
 		},
 		// smaller than any bal
 		Bal: &BallotNum{N: -1},
+		Val: &Value{Vi64: 1},
 	}
 
 	reply, err := kvs.Accept(nil, p)
@@ -32,3 +34,176 @@ func TestAcceptor_Accept_deref_LastBal(t *testing.T) {
 	ta.Equal(int64(0), reply.LastBal.N)
 
 }
+
+func TestAcceptor_Status(t *testing.T) {
+
+	ta := require.New(t)
+
+	kvs, err := NewKVServer(t.TempDir())
+	ta.Nil(err)
+
+	id := &PaxosInstanceId{Key: "x", Ver: 0}
+
+	reply, err := kvs.Prepare(nil, &Proposer{Id: id, Bal: &BallotNum{N: 2}})
+	ta.Nil(err)
+	ta.Equal(AcceptorStatus_Accepted, reply.Status)
+
+	reply, err = kvs.Prepare(nil, &Proposer{Id: id, Bal: &BallotNum{N: 1}})
+	ta.Nil(err)
+	ta.Equal(AcceptorStatus_RejectedHigherBallot, reply.Status)
+	ta.Equal(int64(2), reply.LastBal.N)
+
+	reply, err = kvs.Accept(nil, &Proposer{Id: id, Bal: &BallotNum{N: 1}, Val: &Value{Vi64: 1}})
+	ta.Nil(err)
+	ta.Equal(AcceptorStatus_RejectedHigherBallot, reply.Status)
+
+	reply, err = kvs.Accept(nil, &Proposer{Id: id, Bal: &BallotNum{N: 2}, Val: &Value{Vi64: 2}})
+	ta.Nil(err)
+	ta.Equal(AcceptorStatus_Accepted, reply.Status)
+
+	_, err = kvs.Fence(context.Background(), &FenceRequest{Range: &KeyRange{Start: "y", End: "z"}})
+	ta.Nil(err)
+
+	reply, err = kvs.Commit(nil, &Proposer{Id: &PaxosInstanceId{Key: "y"}, Bal: &BallotNum{N: 2}, Val: &Value{Vi64: 2}})
+	ta.Nil(err)
+	ta.Equal(AcceptorStatus_NotMember, reply.Status)
+
+	// the log fails to write.
+	ta.Nil(kvs.wal.f.Close())
+
+	reply, err = kvs.Prepare(nil, &Proposer{Id: id, Bal: &BallotNum{N: 3}})
+	ta.Nil(err)
+	ta.Equal(AcceptorStatus_StorageError, reply.Status)
+
+	reply, err = kvs.Commit(nil, &Proposer{Id: id, Bal: &BallotNum{N: 3}, Val: &Value{Vi64: 2}})
+	ta.Nil(err)
+	ta.Equal(AcceptorStatus_StorageError, reply.Status)
+
+	state, err := kvs.Inspect(nil, id)
+	ta.Nil(err)
+	ta.Equal(int64(2), state.LastBal.N, "a failed request changes nothing")
+	ta.False(state.Committed)
+}
+
+func TestProposer_Status(t *testing.T) {
+
+	ta := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+
+	servers, err := ServeAcceptors(acceptorIds)
+	ta.Nil(err)
+	defer func() {
+		for _, s := range servers {
+			s.Stop()
+		}
+	}()
+
+	p := &Proposer{Id: &PaxosInstanceId{Key: "x", Ver: 0}, Bal: &BallotNum{N: 1, ProposerId: 1}}
+
+	// an Acceptor not serving the key
+	_, err = servers[0].KVServer.Fence(context.Background(), &FenceRequest{Range: &KeyRange{Start: "x", End: "y"}})
+	ta.Nil(err)
+
+	_, _, err = p.Phase1(acceptorIds, 2)
+	ta.Equal(ShardMoved, err)
+
+	// a quorum fails to store
+	for _, s := range servers[1:] {
+		ta.Nil(s.KVServer.Open(t.TempDir()))
+		ta.Nil(s.KVServer.wal.f.Close())
+	}
+
+	p.Id.Key = "a"
+	_, _, err = p.Phase1(acceptorIds, 2)
+	ta.Equal(StorageFailure, err)
+}
+
+func TestAcceptor_Status_compacted(t *testing.T) {
+
+	ta := require.New(t)
+
+	kvs, err := NewKVServer(t.TempDir())
+	ta.Nil(err)
+
+	bal := &BallotNum{N: 1, ProposerId: 1}
+	for ver, val := range []*Value{{Vi64: 1}, {Deleted: true}} {
+		reply, err := kvs.Commit(nil, &Proposer{Id: &PaxosInstanceId{Key: "x", Ver: int64(ver)}, Bal: bal, Val: val})
+		ta.Nil(err)
+		ta.Equal(AcceptorStatus_Accepted, reply.Status)
+	}
+
+	ta.Equal(0, len(kvs.GC()), "tombstone seen once")
+	ta.Equal([]string{"x"}, kvs.GC())
+
+	for _, ver := range []int64{0, 1} {
+		id := &PaxosInstanceId{Key: "x", Ver: ver}
+
+		reply, err := kvs.Prepare(nil, &Proposer{Id: id, Bal: &BallotNum{N: 2}})
+		ta.Nil(err)
+		ta.Equal(AcceptorStatus_InstanceCompacted, reply.Status)
+
+		reply, err = kvs.Accept(nil, &Proposer{Id: id, Bal: &BallotNum{N: 2}, Val: &Value{Vi64: 2}})
+		ta.Nil(err)
+		ta.Equal(AcceptorStatus_InstanceCompacted, reply.Status)
+
+		reply, err = kvs.Commit(nil, &Proposer{Id: id, Bal: &BallotNum{N: 2}, Val: &Value{Vi64: 2}})
+		ta.Nil(err)
+		ta.Equal(AcceptorStatus_InstanceCompacted, reply.Status)
+
+		state, err := kvs.Inspect(nil, id)
+		ta.Nil(err)
+		ta.Equal(AcceptorStatus_InstanceCompacted, state.Status)
+		ta.Nil(state.Val)
+	}
+
+	// the version after the tombstone is not compacted.
+	reply, err := kvs.Prepare(nil, &Proposer{Id: &PaxosInstanceId{Key: "x", Ver: 2}, Bal: &BallotNum{N: 2}})
+	ta.Nil(err)
+	ta.Equal(AcceptorStatus_Accepted, reply.Status)
+}
+
+func TestProposer_Status_compacted(t *testing.T) {
+
+	ta := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+
+	servers, err := ServeAcceptors(acceptorIds)
+	ta.Nil(err)
+	defer func() {
+		for _, s := range servers {
+			s.Stop()
+		}
+	}()
+
+	c := &KVClient{AcceptorIds: acceptorIds, ProposerId: 2}
+	_, err = c.Set("x", &Value{Vi64: 1})
+	ta.Nil(err)
+	_, err = c.Delete("x")
+	ta.Nil(err)
+
+	for _, s := range servers {
+		s.KVServer.GC()
+		ta.Equal([]string{"x"}, s.KVServer.GC())
+	}
+
+	p := &Proposer{Id: &PaxosInstanceId{Key: "x", Ver: 0}, Bal: &BallotNum{N: 10, ProposerId: 1}}
+
+	_, _, err = p.Phase1(acceptorIds, 2)
+	ta.Equal(InstanceCompacted, err)
+
+	p.Val = &Value{Vi64: 3}
+	_, err = p.Phase2(acceptorIds, 2)
+	ta.Equal(InstanceCompacted, err)
+
+	ta.Nil(p.RunPaxos(acceptorIds, &Value{Vi64: 3}), "nothing is chosen for a compacted version")
+
+	// the old versions read as absent and a write goes after them.
+	_, _, err = c.Get("x")
+	ta.Equal(NotFound, err)
+
+	ver, err := c.Set("x", &Value{Vi64: 4})
+	ta.Nil(err)
+	ta.Equal(int64(2), ver)
+}
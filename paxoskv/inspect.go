@@ -0,0 +1,80 @@
+package paxoskv
+
+import (
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Inspect handles Inspect request.
+// It returns the state of an instance as it is, even if the key is fenced.
+// If the instance does not exist, it returns an error with code NotFound.
+func (s *KVServer) Inspect(c context.Context, id *PaxosInstanceId) (*Acceptor, error) {
+
+	s.log(LevelDebug, "Acceptor: recv Inspect-request", F("key", id.GetKey()), F("ver", id.GetVer()))
+
+	if err := checkInstanceId(id); err != nil {
+		return nil, err
+	}
+	if err := s.ACL.check(c, id.Key, PermRead); err != nil {
+		return nil, err
+	}
+	return s.inspect(id)
+}
+
+// inspect returns the state of an instance, or an error with code NotFound.
+// A compacted instance has only the status InstanceCompacted.
+func (s *KVServer) inspect(id *PaxosInstanceId) (*Acceptor, error) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if floor, found := s.compacted[id.Key]; found && id.Ver <= floor {
+		return &Acceptor{Status: AcceptorStatus_InstanceCompacted}, nil
+	}
+
+	v, found := s.Storage[id.Key][id.Ver]
+	if !found {
+		return nil, status.Errorf(codes.NotFound, "no instance: %s₍%d₎", id.Key, id.Ver)
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return &Acceptor{
+		LastBal:   v.acceptor.LastBal,
+		Val:       v.acceptor.Val,
+		VBal:      v.acceptor.VBal,
+		Committed: v.acceptor.Committed,
+	}, nil
+}
+
+// Inspect returns the raw state of an instance on every Acceptor of the group
+// serving the key, without running paxos. An Acceptor that does not have the
+// instance has a nil state, and one that has compacted it has a state with
+// only the status InstanceCompacted. An Acceptor that fails to reply is absent.
+//
+// It is meant for debugging an instance that does not make progress.
+func (c *KVClient) Inspect(id *PaxosInstanceId) map[int64]*Acceptor {
+
+	states := map[int64]*Acceptor{}
+
+	for _, aid := range c.acceptorsOf(id.Key) {
+		var reply *Acceptor
+		err := callAcceptor(aid, func(ctx context.Context, cli PaxosKVClient) (err error) {
+			reply, err = cli.Inspect(ctx, id)
+			return err
+		})
+		if status.Code(err) == codes.NotFound {
+			states[aid] = nil
+			continue
+		}
+		if err != nil {
+			c.log(LevelWarn, "KVClient: Inspect failure", F("acceptor", aid), F("key", id.Key), F("ver", id.Ver), F("err", err))
+			continue
+		}
+		states[aid] = reply
+	}
+
+	return states
+}
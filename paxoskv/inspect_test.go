@@ -0,0 +1,48 @@
+package paxoskv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKVClient_Inspect(t *testing.T) {
+
+	ta := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+
+	servers, err := ServeAcceptors(acceptorIds)
+	ta.Nil(err)
+	defer func() {
+		for _, s := range servers {
+			s.Stop()
+		}
+	}()
+
+	c := &KVClient{AcceptorIds: acceptorIds, ProposerId: 2}
+
+	// an instance stuck with a promise on only Acceptor-0.
+	p, err := c.newProposer("foo", 0)
+	ta.Nil(err)
+	p.Bal.N = 10
+	p.Phase1([]int64{0}, 1)
+
+	states := c.Inspect(&PaxosInstanceId{Key: "foo", Ver: 0})
+	ta.Equal(3, len(states))
+	ta.Equal(int64(10), states[0].LastBal.N)
+	ta.Nil(states[0].Val)
+	ta.Nil(states[1], "Acceptor-1 does not have the instance")
+
+	_, err = c.Set("foo", &Value{Vi64: 5})
+	ta.Nil(err)
+
+	states = c.Inspect(&PaxosInstanceId{Key: "foo", Ver: 0})
+	for aid := range acceptorIds {
+		ta.True(states[int64(aid)].Committed)
+		ta.Equal(int64(5), states[int64(aid)].Val.Vi64)
+	}
+
+	states = c.Inspect(&PaxosInstanceId{Key: "foo", Ver: 1})
+	ta.Nil(states[0], "Inspect does not create an instance")
+}
@@ -0,0 +1,193 @@
+package paxoskv
+
+import (
+	"strings"
+	"sync"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// KVService implements the client-facing KVService API by running the
+// Proposer with a KVClient on the server side.
+type KVService struct {
+	UnimplementedKVServiceServer
+
+	// ACL, if not nil, is the permissions on keys required by requests:
+	// write for Put and Delete, and read for Get. Scan returns only the
+	// readable keys.
+	ACL *ACL
+
+	mu     sync.RWMutex
+	client *KVClient
+}
+
+// NewKVService creates a KVService proposing with `c`.
+// The ProposerId of `c` must be unique among all proposers of the cluster.
+//
+// `c` may be nil, e.g., when the ProposerId is not yet allocated. Requests
+// fail with code Unavailable until a client is set with SetClient.
+func NewKVService(c *KVClient) *KVService {
+	return &KVService{client: c}
+}
+
+// SetClient sets the KVClient to propose with.
+func (s *KVService) SetClient(c *KVClient) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.client = c
+}
+
+// getClient returns the KVClient to propose with, or an Unavailable error if
+// it is not set.
+func (s *KVService) getClient() (*KVClient, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.client == nil {
+		return nil, status.Error(codes.Unavailable, "proposer is not ready")
+	}
+	return s.client, nil
+}
+
+// Put handles Put request.
+// Put, Get and Delete give up when `ctx` is done, with code DeadlineExceeded or
+// Canceled. A Put or Delete given up may still take effect.
+func (s *KVService) Put(ctx context.Context, r *Record) (*Record, error) {
+
+	loggerOr(nil).Log(LevelDebug, "KVService: recv Put-request", F("key", r.Key))
+
+	if err := checkUserKey(r.Key); err != nil {
+		return nil, err
+	}
+	if r.Val == nil {
+		return nil, status.Error(codes.InvalidArgument, "no value")
+	}
+	if err := checkValue(r.Val); err != nil {
+		return nil, err
+	}
+	if err := s.ACL.check(ctx, r.Key, PermWrite); err != nil {
+		return nil, err
+	}
+
+	c, err := s.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	ver, err := c.SetContext(ctx, r.Key, r.Val)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &Record{Key: r.Key, Ver: ver, Val: r.Val}, nil
+}
+
+// Get handles Get request.
+func (s *KVService) Get(ctx context.Context, r *Record) (*Record, error) {
+
+	loggerOr(nil).Log(LevelDebug, "KVService: recv Get-request", F("key", r.Key))
+
+	if err := checkUserKey(r.Key); err != nil {
+		return nil, err
+	}
+	if err := s.ACL.check(ctx, r.Key, PermRead); err != nil {
+		return nil, err
+	}
+
+	c, err := s.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	v, ver, err := c.GetContext(ctx, r.Key)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &Record{Key: r.Key, Ver: ver, Val: v}, nil
+}
+
+// Delete handles Delete request.
+func (s *KVService) Delete(ctx context.Context, r *Record) (*Record, error) {
+
+	loggerOr(nil).Log(LevelDebug, "KVService: recv Delete-request", F("key", r.Key))
+
+	if err := checkUserKey(r.Key); err != nil {
+		return nil, err
+	}
+	if err := s.ACL.check(ctx, r.Key, PermWrite); err != nil {
+		return nil, err
+	}
+
+	c, err := s.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	ver, err := c.DeleteContext(ctx, r.Key)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &Record{Key: r.Key, Ver: ver, Val: &Value{Deleted: true}}, nil
+}
+
+// Scan handles Scan request.
+func (s *KVService) Scan(ctx context.Context, r *KeyRange) (*RecordList, error) {
+
+	loggerOr(nil).Log(LevelDebug, "KVService: recv Scan-request", F("start", r.Start), F("end", r.End))
+
+	c, err := s.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	if s.ACL == nil {
+		records, err := c.Scan(r.Start, r.End, int(r.Limit))
+		if err != nil {
+			return nil, toStatus(err)
+		}
+		return &RecordList{Records: records}, nil
+	}
+
+	ids, err := s.ACL.identities(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// keys the requester may not read are not read at all.
+	records, err := c.scan(r.Start, r.End, int(r.Limit), func(key string) bool {
+		return s.ACL.allowed(ids, key, PermRead)
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &RecordList{Records: records}, nil
+}
+
+// checkUserKey returns an InvalidArgument error if a key can not be used by
+// clients.
+func checkUserKey(key string) error {
+	if key == "" {
+		return status.Error(codes.InvalidArgument, "empty key")
+	}
+	if strings.HasPrefix(key, internalKeyPrefix) {
+		return status.Errorf(codes.InvalidArgument, "reserved key: %q", key)
+	}
+	return nil
+}
+
+// toStatus converts an error of KVClient to a gRPC status error.
+func toStatus(err error) error {
+	switch err {
+	case NotFound:
+		return status.Error(codes.NotFound, err.Error())
+	case NotEnoughQuorum:
+		return status.Error(codes.Unavailable, err.Error())
+	case context.DeadlineExceeded:
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case context.Canceled:
+		return status.Error(codes.Canceled, err.Error())
+	default:
+		return status.Error(codes.Unknown, err.Error())
+	}
+}
@@ -0,0 +1,119 @@
+package paxoskv
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestKVService(t *testing.T) {
+
+	ta := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+
+	servers, err := ServeAcceptors(acceptorIds)
+	ta.Nil(err)
+	defer func() {
+		for _, s := range servers {
+			s.Stop()
+		}
+	}()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	ta.Nil(err)
+
+	gs := grpc.NewServer()
+	RegisterKVServiceServer(gs, NewKVService(&KVClient{AcceptorIds: acceptorIds, ProposerId: 2}))
+	go gs.Serve(lis)
+	defer gs.Stop()
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	ta.Nil(err)
+	defer conn.Close()
+
+	cli := NewKVServiceClient(conn)
+	ctx := context.Background()
+
+	_, err = cli.Get(ctx, &Record{Key: "foo"})
+	ta.Equal(codes.NotFound, status.Code(err))
+
+	for i, k := range []string{"foo", "bar", "foo"} {
+		_, err = cli.Put(ctx, &Record{Key: k, Val: &Value{Vi64: int64(i)}})
+		ta.Nil(err)
+	}
+
+	rec, err := cli.Get(ctx, &Record{Key: "foo"})
+	ta.Nil(err)
+	ta.Equal(int64(1), rec.Ver)
+	ta.Equal(int64(2), rec.Val.Vi64)
+
+	list, err := cli.Scan(ctx, &KeyRange{})
+	ta.Nil(err)
+	ta.Equal(2, len(list.Records))
+	ta.Equal("bar", list.Records[0].Key)
+	ta.Equal("foo", list.Records[1].Key)
+
+	rec, err = cli.Delete(ctx, &Record{Key: "foo"})
+	ta.Nil(err)
+	ta.Equal(int64(2), rec.Ver)
+	ta.True(rec.Val.Deleted)
+
+	_, err = cli.Delete(ctx, &Record{Key: "foo"})
+	ta.Equal(codes.NotFound, status.Code(err))
+
+	_, err = cli.Put(ctx, &Record{Key: "", Val: &Value{}})
+	ta.Equal(codes.InvalidArgument, status.Code(err))
+	_, err = cli.Put(ctx, &Record{Key: internalKeyPrefix + "txn/x", Val: &Value{}})
+	ta.Equal(codes.InvalidArgument, status.Code(err))
+	_, err = cli.Put(ctx, &Record{Key: "foo"})
+	ta.Equal(codes.InvalidArgument, status.Code(err))
+}
+
+func TestKVService_NotReady(t *testing.T) {
+
+	ta := require.New(t)
+
+	s := NewKVService(nil)
+	_, err := s.Get(context.Background(), &Record{Key: "foo"})
+	ta.Equal(codes.Unavailable, status.Code(err))
+}
+
+func TestKVService_ContextDone(t *testing.T) {
+
+	ta := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+
+	servers, err := ServeAcceptors(acceptorIds)
+	ta.Nil(err)
+	defer servers[0].Stop()
+
+	// no quorum: a paxos never finishes.
+	servers[1].Stop()
+	servers[2].Stop()
+
+	s := NewKVService(&KVClient{AcceptorIds: acceptorIds, ProposerId: 2})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	_, err = s.Put(ctx, &Record{Key: "foo", Val: &Value{Vi64: 1}})
+	ta.Equal(codes.DeadlineExceeded, status.Code(err))
+	_, err = s.Get(ctx, &Record{Key: "foo"})
+	ta.Equal(codes.DeadlineExceeded, status.Code(err))
+	_, err = s.Delete(ctx, &Record{Key: "foo"})
+	ta.Equal(codes.DeadlineExceeded, status.Code(err))
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+
+	_, err = s.Get(ctx, &Record{Key: "foo"})
+	ta.Equal(codes.Canceled, status.Code(err))
+}
@@ -0,0 +1,100 @@
+package paxoskv
+
+import (
+	"errors"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+var (
+	LockHeld = errors.New("lock is held by others")
+	LockLost = errors.New("lock is not held")
+)
+
+// Lock acquires the lock named `key` for `ttl` and returns a fencing token.
+// A `ttl` <= 0 means the lock never expires.
+//
+// A lock is a key whose latest visible version is not a tombstone. Acquiring it
+// is writing the version after a tombstone, or after an expired version, with
+// CompareAndSet.
+//
+// The fencing token is the version at which the lock is acquired. A later
+// acquisition of the same lock always has a greater token, thus a resource
+// rejecting tokens smaller than the greatest one it has seen is safe from a
+// holder whose lock has expired.
+// The lock Value stores the token in Vi64.
+//
+// If the lock is held by others, it returns a LockHeld error.
+func (c *KVClient) Lock(key string, ttl time.Duration) (int64, error) {
+
+	v, _, ver, err := c.read(context.Background(), key)
+	if err != nil {
+		return 0, err
+	}
+	if v != nil && !v.Deleted {
+		return 0, LockHeld
+	}
+
+	token := ver + 1
+	ok, err := c.CompareAndSet(key, ver, newLockValue(token, ttl))
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return 0, LockHeld
+	}
+
+	c.log(LevelDebug, "KVClient: locked", F("key", key), F("token", token))
+	return token, nil
+}
+
+// Renew extends a lock held with `token` to expire after `ttl` from now.
+// The token does not change.
+//
+// If the lock is not held with `token`, e.g., it has expired, it returns a
+// LockLost error.
+func (c *KVClient) Renew(key string, token int64, ttl time.Duration) error {
+	return c.updateLock(key, token, newLockValue(token, ttl))
+}
+
+// Unlock releases a lock held with `token` by writing a tombstone.
+//
+// If the lock is not held with `token`, it returns a LockLost error.
+func (c *KVClient) Unlock(key string, token int64) error {
+	return c.updateLock(key, token, &Value{Deleted: true})
+}
+
+// updateLock writes `val` as the next version of a lock, if the lock is held
+// with `token`.
+func (c *KVClient) updateLock(key string, token int64, val *Value) error {
+	for {
+		v, _, ver, err := c.read(context.Background(), key)
+		if err != nil {
+			return err
+		}
+		if v == nil || v.Deleted || v.Vi64 != token {
+			return LockLost
+		}
+
+		ok, err := c.CompareAndSet(key, ver, val)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+
+		// Another version is written, by an expiration, or by a racing
+		// Renew or Unlock. Check again if the lock is still held.
+	}
+}
+
+// newLockValue builds the Value of a lock held with `token` for `ttl`.
+func newLockValue(token int64, ttl time.Duration) *Value {
+	v := &Value{Vi64: token}
+	if ttl > 0 {
+		v.ExpireAt = time.Now().Add(ttl).UnixNano()
+	}
+	return v
+}
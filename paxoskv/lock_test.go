@@ -0,0 +1,115 @@
+package paxoskv
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKVClient_Lock(t *testing.T) {
+
+	ta := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+
+	servers, err := ServeAcceptors(acceptorIds)
+	ta.Nil(err)
+	defer func() {
+		for _, s := range servers {
+			s.Stop()
+		}
+	}()
+
+	a := &KVClient{AcceptorIds: acceptorIds, ProposerId: 2}
+	b := &KVClient{AcceptorIds: acceptorIds, ProposerId: 3}
+
+	token, err := a.Lock("lk", 0)
+	ta.Nil(err)
+	ta.Equal(int64(0), token)
+
+	_, err = b.Lock("lk", 0)
+	ta.Equal(LockHeld, err)
+
+	ta.Equal(LockLost, b.Unlock("lk", token+1), "wrong token")
+	ta.Nil(a.Renew("lk", token, time.Hour))
+	ta.Nil(a.Unlock("lk", token))
+	ta.Equal(LockLost, a.Unlock("lk", token), "already unlocked")
+
+	token2, err := b.Lock("lk", 0)
+	ta.Nil(err)
+	ta.Equal(int64(3), token2, "lock, renew, unlock, then lock")
+	ta.Nil(b.Unlock("lk", token2))
+}
+
+func TestKVClient_Lock_expire(t *testing.T) {
+
+	ta := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+
+	servers, err := ServeAcceptors(acceptorIds)
+	ta.Nil(err)
+	defer func() {
+		for _, s := range servers {
+			s.Stop()
+		}
+	}()
+
+	a := &KVClient{AcceptorIds: acceptorIds, ProposerId: 2}
+	b := &KVClient{AcceptorIds: acceptorIds, ProposerId: 3}
+
+	token, err := a.Lock("lk", 100*time.Millisecond)
+	ta.Nil(err)
+
+	_, err = b.Lock("lk", time.Hour)
+	ta.Equal(LockHeld, err)
+
+	// a dies and its lock expires.
+
+	time.Sleep(150 * time.Millisecond)
+
+	token2, err := b.Lock("lk", time.Hour)
+	ta.Nil(err)
+	ta.True(token2 > token, "fencing token increases")
+
+	ta.Equal(LockLost, a.Renew("lk", token, time.Hour))
+	ta.Equal(LockLost, a.Unlock("lk", token))
+	ta.Nil(b.Renew("lk", token2, time.Hour))
+}
+
+func TestKVClient_CompareAndSet(t *testing.T) {
+
+	ta := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+
+	servers, err := ServeAcceptors(acceptorIds)
+	ta.Nil(err)
+	defer func() {
+		for _, s := range servers {
+			s.Stop()
+		}
+	}()
+
+	a := &KVClient{AcceptorIds: acceptorIds, ProposerId: 2}
+	b := &KVClient{AcceptorIds: acceptorIds, ProposerId: 3}
+
+	ok, err := a.CompareAndSet("x", -1, &Value{Vi64: 1})
+	ta.Nil(err)
+	ta.True(ok)
+
+	// b writes an equal value based on a stale version.
+	ok, err = b.CompareAndSet("x", -1, &Value{Vi64: 1})
+	ta.Nil(err)
+	ta.False(ok, "an equal value written by others is not b's")
+
+	ok, err = b.CompareAndSet("x", 0, &Value{Vi64: 2})
+	ta.Nil(err)
+	ta.True(ok)
+
+	v, ver, err := a.Get("x")
+	ta.Nil(err)
+	ta.Equal(int64(2), v.Vi64)
+	ta.Equal(int64(1), ver)
+}
@@ -0,0 +1,138 @@
+package paxoskv
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level is the severity of a log entry.
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+
+	// LevelNone is above every level, to log nothing.
+	LevelNone
+)
+
+var levelNames = []string{"DEBUG", "INFO", "WARN", "ERROR", "NONE"}
+
+func (l Level) String() string {
+	if l < 0 || int(l) >= len(levelNames) {
+		return fmt.Sprintf("Level(%d)", int(l))
+	}
+	return levelNames[l]
+}
+
+// ParseLevel parses a level name, such as "info", case-insensitively.
+func ParseLevel(s string) (Level, error) {
+	for i, name := range levelNames {
+		if strings.EqualFold(s, name) {
+			return Level(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown log level: %q", s)
+}
+
+// Field is a key-value pair attached to a log entry, such as the key, the
+// version, the ballot number or the Acceptor id an entry is about.
+type Field struct {
+	Key string
+	Val interface{}
+}
+
+// F creates a Field.
+func F(key string, val interface{}) Field {
+	return Field{Key: key, Val: val}
+}
+
+// Logger receives the log entries of Acceptors, Proposers and clients.
+type Logger interface {
+	Log(level Level, msg string, fields ...Field)
+}
+
+// NopLogger discards every log entry. It is the default Logger.
+var NopLogger Logger = nopLogger{}
+
+type nopLogger struct{}
+
+func (nopLogger) Log(level Level, msg string, fields ...Field) {}
+
+// TextLogger writes every log entry at or above Level as a line:
+//
+//	2006-01-02T15:04:05.000000Z07:00 INFO Acceptor: GC dropped key key="foo" ver=3
+type TextLogger struct {
+	Level Level
+
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewTextLogger creates a TextLogger writing to `w` the entries at or above
+// `level`.
+func NewTextLogger(w io.Writer, level Level) *TextLogger {
+	return &TextLogger{Level: level, w: w}
+}
+
+func (l *TextLogger) Log(level Level, msg string, fields ...Field) {
+	if level < l.Level {
+		return
+	}
+
+	b := &strings.Builder{}
+	b.WriteString(time.Now().Format("2006-01-02T15:04:05.000000Z07:00"))
+	b.WriteString(" ")
+	b.WriteString(level.String())
+	b.WriteString(" ")
+	b.WriteString(msg)
+	for _, f := range fields {
+		if s, ok := f.Val.(string); ok {
+			fmt.Fprintf(b, " %s=%q", f.Key, s)
+		} else {
+			fmt.Fprintf(b, " %s=%v", f.Key, f.Val)
+		}
+	}
+	b.WriteString("\n")
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	io.WriteString(l.w, b.String())
+}
+
+var (
+	// loggerMu protects defaultLogger.
+	loggerMu sync.RWMutex
+
+	// defaultLogger is used where no Logger is given.
+	defaultLogger = NopLogger
+)
+
+// SetLogger sets the Logger used where no Logger is given: by a KVServer or a
+// KVClient whose Logger is nil, and by the exported methods of Proposer, which
+// is a generated message and can not hold one. A nil `l` restores NopLogger.
+func SetLogger(l Logger) {
+	loggerMu.Lock()
+	defer loggerMu.Unlock()
+
+	if l == nil {
+		l = NopLogger
+	}
+	defaultLogger = l
+}
+
+// loggerOr returns `l`, or the default Logger if `l` is nil.
+func loggerOr(l Logger) Logger {
+	if l != nil {
+		return l
+	}
+
+	loggerMu.RLock()
+	defer loggerMu.RUnlock()
+	return defaultLogger
+}
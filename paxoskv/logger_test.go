@@ -0,0 +1,126 @@
+package paxoskv
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+// testLogger keeps every entry it receives.
+type testLogger struct {
+	mu      sync.Mutex
+	entries []testEntry
+}
+
+type testEntry struct {
+	level  Level
+	msg    string
+	fields map[string]interface{}
+}
+
+func (l *testLogger) Log(level Level, msg string, fields ...Field) {
+	e := testEntry{level: level, msg: msg, fields: map[string]interface{}{}}
+	for _, f := range fields {
+		e.fields[f.Key] = f.Val
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, e)
+}
+
+// find returns the first entry with message `msg`.
+func (l *testLogger) find(msg string) *testEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for i := range l.entries {
+		if l.entries[i].msg == msg {
+			return &l.entries[i]
+		}
+	}
+	return nil
+}
+
+func TestTextLogger(t *testing.T) {
+
+	ta := require.New(t)
+
+	buf := &bytes.Buffer{}
+	l := NewTextLogger(buf, LevelInfo)
+
+	l.Log(LevelDebug, "dropped")
+	ta.Equal("", buf.String())
+
+	l.Log(LevelWarn, "Acceptor: hello", F("key", "foo"), F("ver", int64(3)))
+	line := buf.String()
+	ta.True(strings.HasSuffix(line, ` WARN Acceptor: hello key="foo" ver=3`+"\n"), line)
+
+	lvl, err := ParseLevel("Debug")
+	ta.Nil(err)
+	ta.Equal(LevelDebug, lvl)
+
+	_, err = ParseLevel("verbose")
+	ta.NotNil(err)
+}
+
+func TestKVServer_Logger(t *testing.T) {
+
+	ta := require.New(t)
+
+	lg := &testLogger{}
+	s, err := NewKVServer("")
+	ta.Nil(err)
+	s.Id = 7
+	s.Logger = lg
+
+	_, err = s.Prepare(context.Background(), &Proposer{
+		Id:  &PaxosInstanceId{Key: "foo", Ver: 1},
+		Bal: &BallotNum{N: 2, ProposerId: 3},
+	})
+	ta.Nil(err)
+
+	e := lg.find("Acceptor: recv Prepare-request")
+	ta.NotNil(e)
+	ta.Equal(LevelDebug, e.level)
+	ta.Equal(int64(7), e.fields["acceptor"])
+	ta.Equal("foo", e.fields["key"])
+	ta.Equal(int64(1), e.fields["ver"])
+	ta.NotNil(e.fields["bal"])
+}
+
+func TestKVClient_Logger(t *testing.T) {
+
+	ta := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+
+	servers, err := ServeAcceptors(acceptorIds)
+	ta.Nil(err)
+	defer func() {
+		for _, s := range servers {
+			s.Stop()
+		}
+	}()
+
+	lg := &testLogger{}
+	c := &KVClient{AcceptorIds: acceptorIds, ProposerId: 2, Logger: lg}
+
+	_, err = c.Set("foo", &Value{Vi64: 1})
+	ta.Nil(err)
+
+	// the Proposers run by the client log to the client Logger.
+	e := lg.find("Proposer: value is voted by a quorum and has been safe")
+	ta.NotNil(e)
+	ta.Equal("foo", e.fields["key"])
+	ta.Equal(int64(0), e.fields["ver"])
+	ta.NotNil(e.fields["bal"])
+
+	e = lg.find("Proposer: recv Prepare reply")
+	ta.NotNil(e)
+	ta.Contains(acceptorIds, e.fields["acceptor"])
+}
@@ -0,0 +1,208 @@
+package paxoskv
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Metrics of proposers, shared by all proposers in a process.
+var (
+	phaseAttempts = newMetricVec("paxoskv_proposer_phase_attempts_total", "counter",
+		"Number of phase-1 and phase-2 runs.", nil, "phase")
+
+	higherBallotRejections = newMetricVec("paxoskv_proposer_higher_ballot_rejections_total", "counter",
+		"Number of Prepare and Accept replies with a higher ballot than the proposer's.", nil, "phase")
+
+	runPaxosRetries = newMetricVec("paxoskv_proposer_run_paxos_retries", "histogram",
+		"Number of ballot increments per RunPaxos.", []float64{0, 1, 2, 3, 5, 10, 20, 50})
+
+	rpcDuration = newMetricVec("paxoskv_proposer_rpc_duration_seconds", "histogram",
+		"Latency of RPCs to an Acceptor.",
+		[]float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1}, "acceptor", "method")
+
+	rpcFailures = newMetricVec("paxoskv_proposer_rpc_failures_total", "counter",
+		"Number of failed RPCs to an Acceptor.", nil, "acceptor", "method")
+
+	proposerMetrics = []*metricVec{phaseAttempts, higherBallotRejections, runPaxosRetries, rpcDuration, rpcFailures}
+)
+
+// metricVec is a counter or a histogram with a series for every combination
+// of label values.
+type metricVec struct {
+	name   string
+	typ    string
+	help   string
+	labels []string
+
+	// buckets are the upper bounds of the buckets of a histogram.
+	buckets []float64
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+type series struct {
+	labelValues []string
+
+	// value is the value of a counter, or the sum of a histogram.
+	value float64
+
+	// counts are the number of observations in every bucket of a histogram.
+	counts []uint64
+	count  uint64
+}
+
+func newMetricVec(name, typ, help string, buckets []float64, labels ...string) *metricVec {
+	return &metricVec{
+		name:    name,
+		typ:     typ,
+		help:    help,
+		labels:  labels,
+		buckets: buckets,
+		series:  map[string]*series{},
+	}
+}
+
+// getSeries returns the series of the label values, creating it if absent.
+// It must be called with mu held.
+func (m *metricVec) getSeries(labelValues []string) *series {
+	k := strings.Join(labelValues, "\xff")
+	s, found := m.series[k]
+	if !found {
+		s = &series{labelValues: labelValues, counts: make([]uint64, len(m.buckets))}
+		m.series[k] = s
+	}
+	return s
+}
+
+// Inc adds 1 to a counter.
+func (m *metricVec) Inc(labelValues ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.getSeries(labelValues).value++
+}
+
+// Observe adds an observation to a histogram.
+func (m *metricVec) Observe(v float64, labelValues ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s := m.getSeries(labelValues)
+	for i, le := range m.buckets {
+		if v <= le {
+			s.counts[i]++
+		}
+	}
+	s.value += v
+	s.count++
+}
+
+// get returns the value of a counter, or the number of observations of a
+// histogram.
+func (m *metricVec) get(labelValues ...string) float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s := m.getSeries(labelValues)
+	if m.typ == "histogram" {
+		return float64(s.count)
+	}
+	return s.value
+}
+
+// writeTo writes the metric in the Prometheus text exposition format.
+func (m *metricVec) writeTo(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n", m.name, m.help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", m.name, m.typ)
+
+	keys := make([]string, 0, len(m.series))
+	for k := range m.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		s := m.series[k]
+		if m.typ != "histogram" {
+			writeSample(w, m.name, m.labels, s.labelValues, s.value)
+			continue
+		}
+
+		labels := append(append([]string{}, m.labels...), "le")
+		for i, le := range m.buckets {
+			lvs := append(append([]string{}, s.labelValues...), formatFloat(le))
+			writeSample(w, m.name+"_bucket", labels, lvs, float64(s.counts[i]))
+		}
+		lvs := append(append([]string{}, s.labelValues...), "+Inf")
+		writeSample(w, m.name+"_bucket", labels, lvs, float64(s.count))
+		writeSample(w, m.name+"_sum", m.labels, s.labelValues, s.value)
+		writeSample(w, m.name+"_count", m.labels, s.labelValues, float64(s.count))
+	}
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// writeSample writes a line such as `name{a="1",b="2"} 3`.
+func writeSample(w io.Writer, name string, labels, labelValues []string, v float64) {
+	io.WriteString(w, name)
+	if len(labels) > 0 {
+		pairs := make([]string, len(labels))
+		for i, l := range labels {
+			pairs[i] = l + `="` + labelEscaper.Replace(labelValues[i]) + `"`
+		}
+		io.WriteString(w, "{"+strings.Join(pairs, ",")+"}")
+	}
+	io.WriteString(w, " "+formatFloat(v)+"\n")
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// MetricsHandler serves the metrics of the proposers in this process and of
+// the Acceptors `servers` in the Prometheus text exposition format, e.g., on
+// /metrics.
+type MetricsHandler struct {
+	Servers []*KVServer
+}
+
+// NewMetricsHandler creates a MetricsHandler exporting the instance counts of
+// `servers`.
+func NewMetricsHandler(servers ...*KVServer) *MetricsHandler {
+	return &MetricsHandler{Servers: servers}
+}
+
+func (h *MetricsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	for _, m := range proposerMetrics {
+		m.writeTo(w)
+	}
+
+	if len(h.Servers) == 0 {
+		return
+	}
+
+	keys := newMetricVec("paxoskv_acceptor_keys", "gauge", "Number of keys in the Storage of an Acceptor.", nil, "acceptor")
+	instances := newMetricVec("paxoskv_acceptor_instances", "gauge", "Number of paxos instances in the Storage of an Acceptor.", nil, "acceptor")
+
+	for _, s := range h.Servers {
+		aid := strconv.FormatInt(s.Id, 10)
+		st := s.stats()
+		keys.getSeries([]string{aid}).value = float64(st.Keys)
+		instances.getSeries([]string{aid}).value = float64(st.Instances)
+	}
+
+	keys.writeTo(w)
+	instances.writeTo(w)
+}
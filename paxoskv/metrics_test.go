@@ -0,0 +1,93 @@
+package paxoskv
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+func TestMetrics(t *testing.T) {
+
+	ta := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+
+	servers, err := ServeAcceptors(acceptorIds)
+	ta.Nil(err)
+	defer func() {
+		for _, s := range servers {
+			s.Stop()
+		}
+	}()
+
+	id := &PaxosInstanceId{Key: "metrics", Ver: 0}
+
+	phase1 := phaseAttempts.get("1")
+	rejected := higherBallotRejections.get("1")
+	runs := runPaxosRetries.get()
+	prepares := rpcDuration.get("0", "Prepare")
+
+	// a higher ballot prepared by another proposer rejects the lower one.
+	higher := &Proposer{Id: id, Bal: &BallotNum{N: 10, ProposerId: 1}}
+	_, _, err = higher.Phase1(acceptorIds, 2)
+	ta.Nil(err)
+
+	p := &Proposer{Id: id, Bal: &BallotNum{N: 1, ProposerId: 2}}
+	p.RunPaxos(acceptorIds, &Value{Vi64: 5})
+
+	ta.True(phaseAttempts.get("1") >= phase1+3)
+	ta.True(higherBallotRejections.get("1") >= rejected+3)
+	ta.True(runPaxosRetries.get() >= runs+1)
+	ta.True(rpcDuration.get("0", "Prepare") >= prepares+3)
+
+	kvs, err := NewKVServer("")
+	ta.Nil(err)
+	kvs.Id = 7
+	for _, ver := range []int64{0, 1} {
+		_, err = kvs.Prepare(context.Background(), &Proposer{
+			Id:  &PaxosInstanceId{Key: "foo", Ver: ver},
+			Bal: &BallotNum{N: 1, ProposerId: 1},
+		})
+		ta.Nil(err)
+	}
+
+	w := httptest.NewRecorder()
+	NewMetricsHandler(kvs).ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	body, err := io.ReadAll(w.Body)
+	ta.Nil(err)
+	out := string(body)
+
+	ta.Contains(w.Header().Get("Content-Type"), "text/plain")
+	ta.Contains(out, "# TYPE paxoskv_proposer_phase_attempts_total counter\n")
+	ta.Contains(out, `paxoskv_proposer_phase_attempts_total{phase="2"} `)
+	ta.Contains(out, "# TYPE paxoskv_proposer_run_paxos_retries histogram\n")
+	ta.Contains(out, `paxoskv_proposer_run_paxos_retries_bucket{le="+Inf"} `)
+	ta.Contains(out, `paxoskv_proposer_rpc_duration_seconds_bucket{acceptor="1",method="Accept",le="0.001"} `)
+	ta.Contains(out, `paxoskv_proposer_rpc_duration_seconds_count{acceptor="2",method="Commit"} `)
+	ta.Contains(out, `paxoskv_acceptor_keys{acceptor="7"} 1`+"\n")
+	ta.Contains(out, `paxoskv_acceptor_instances{acceptor="7"} 2`+"\n")
+}
+
+func TestMetricVec_Histogram(t *testing.T) {
+
+	ta := require.New(t)
+
+	m := newMetricVec("h", "histogram", "test.", []float64{1, 2}, "a")
+	m.Observe(0.5, `x"y`)
+	m.Observe(2, `x"y`)
+	m.Observe(3, `x"y`)
+
+	w := httptest.NewRecorder()
+	m.writeTo(w)
+	ta.Equal(`# HELP h test.
+# TYPE h histogram
+h_bucket{a="x\"y",le="1"} 1
+h_bucket{a="x\"y",le="2"} 2
+h_bucket{a="x\"y",le="+Inf"} 3
+h_sum{a="x\"y"} 5.5
+h_count{a="x\"y"} 3
+`, w.Body.String())
+}
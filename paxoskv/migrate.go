@@ -0,0 +1,434 @@
+package paxoskv
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/protobuf/proto"
+)
+
+// shardKeyPrefix is the key prefix of shard boundaries in the meta group.
+// A shard starting at `start` is stored as key shardKeyPrefix + start, with the
+// group id in Vi64. A shard ends where the next one starts.
+const shardKeyPrefix = internalKeyPrefix + "shard/"
+
+// Fence handles Fence request.
+//
+// Without Unfence, it refuses Prepare, Accept and Commit for keys in r.Range
+// from now on, and replies the state of every instance in the range. An
+// instance being handled is waited for, thus no instance changes after it is
+// replied.
+//
+// With Unfence, it serves the range again and drops all instances in it: they
+// are left by a previous move of the range to another group and are obsolete.
+// The versions in r.Compacted are compacted in the source group of a move, they
+// are refused from now on, as if GC compacted them here.
+func (s *KVServer) Fence(c context.Context, r *FenceRequest) (*FenceReply, error) {
+
+	s.log(LevelInfo, "Acceptor: recv Fence-request", F("range", r.Range), F("unfence", r.Unfence))
+
+	// a range is not a prefix: it needs the permission on all keys.
+	if err := s.ACL.check(c, "", PermWrite); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rng := &KeyRange{}
+	if r.Range != nil {
+		rng = &KeyRange{Start: r.Range.Start, End: r.Range.End}
+	}
+
+	i := sort.SearchStrings(s.keys, rng.Start)
+	j := i
+	for ; j < len(s.keys); j++ {
+		if rng.End != "" && s.keys[j] >= rng.End {
+			break
+		}
+	}
+	keys := append([]string{}, s.keys[i:j]...)
+
+	entries := []*LogEntry{{Fence: &FenceRequest{Range: rng, Unfence: r.Unfence}}}
+	if r.Unfence {
+		// the compacted versions are forgotten too: the instances moved in
+		// are run again at the versions they have in the source group.
+		dropped := append([]string{}, keys...)
+		for key := range s.compacted {
+			if key >= rng.Start && (rng.End == "" || key < rng.End) {
+				if _, found := s.Storage[key]; !found {
+					dropped = append(dropped, key)
+				}
+			}
+		}
+		for _, key := range dropped {
+			entries = append(entries, &LogEntry{Drop: &PaxosInstanceId{Key: key}})
+		}
+
+		floors := make([]string, 0, len(r.Compacted))
+		for key := range r.Compacted {
+			if key >= rng.Start && (rng.End == "" || key < rng.End) {
+				floors = append(floors, key)
+			}
+		}
+		sort.Strings(floors)
+		for _, key := range floors {
+			entries = append(entries, &LogEntry{Compact: &PaxosInstanceId{Key: key, Ver: r.Compacted[key]}})
+		}
+	}
+	if err := s.writeLog(entries...); err != nil {
+		return nil, err
+	}
+
+	if r.Unfence {
+		s.removeFence(rng)
+		for _, e := range entries[1:] {
+			s.apply(e)
+			if e.Drop != nil {
+				delete(s.tombstoned, e.Drop.Key)
+			}
+		}
+		return &FenceReply{}, nil
+	}
+
+	s.fences = append(s.fences, rng)
+
+	reply := &FenceReply{Compacted: map[string]int64{}}
+	for key, floor := range s.compacted {
+		if key >= rng.Start && (rng.End == "" || key < rng.End) {
+			reply.Compacted[key] = floor
+		}
+	}
+	for _, key := range keys {
+		rec := s.Storage[key]
+		vers := make([]int64, 0, len(rec))
+		for ver := range rec {
+			vers = append(vers, ver)
+		}
+		sort.Slice(vers, func(i, j int) bool { return vers[i] < vers[j] })
+
+		for _, ver := range vers {
+			v := rec[ver]
+			v.mu.Lock()
+			reply.Instances = append(reply.Instances, &Instance{
+				Id: &PaxosInstanceId{Key: key, Ver: ver},
+				Acceptor: &Acceptor{
+					LastBal:   v.acceptor.LastBal,
+					Val:       v.acceptor.Val,
+					VBal:      v.acceptor.VBal,
+					Committed: v.acceptor.Committed,
+				},
+			})
+			v.mu.Unlock()
+		}
+	}
+
+	return reply, nil
+}
+
+// fenced returns whether a key is in a fenced range.
+// It must be called with s.mu held.
+func (s *KVServer) fenced(key string) bool {
+	for _, f := range s.fences {
+		if key >= f.Start && (f.End == "" || key < f.End) {
+			return true
+		}
+	}
+	return false
+}
+
+// removeFence removes range `r` from the fenced ranges.
+// It must be called with s.mu held.
+func (s *KVServer) removeFence(r *KeyRange) {
+	fences := []*KeyRange{}
+	for _, f := range s.fences {
+		// the part of f before r
+		if f.Start < r.Start {
+			end := f.End
+			if end == "" || end > r.Start {
+				end = r.Start
+			}
+			fences = append(fences, &KeyRange{Start: f.Start, End: end})
+		}
+		// the part of f after r
+		if r.End != "" && (f.End == "" || f.End > r.End) {
+			start := f.Start
+			if start < r.End {
+				start = r.End
+			}
+			fences = append(fences, &KeyRange{Start: start, End: f.End})
+		}
+	}
+	s.fences = fences
+}
+
+// InitShards stores Shards into the meta group, if the meta group has no shard
+// map yet. Every shard already stored is kept.
+func (c *KVClient) InitShards() error {
+	meta := c.metaClient()
+	for _, shard := range c.shardMap().Shards {
+		_, err := meta.CompareAndSet(shardKeyPrefix+shard.Start, -1, &Value{Vi64: shard.GroupId})
+		if err != nil {
+			return err
+		}
+	}
+	return c.LoadShards()
+}
+
+// LoadShards reloads Shards from the meta group.
+// The groups are not stored in the meta group, they are kept as they are.
+func (c *KVClient) LoadShards() error {
+
+	meta := c.metaClient()
+	keys, err := meta.listKeys(c.MetaAcceptorIds, shardKeyPrefix, prefixEnd(shardKeyPrefix), 0)
+	if err != nil {
+		return err
+	}
+
+	shards := []*Shard{}
+	for _, key := range keys {
+		v, _, err := meta.Get(key)
+		if err == NotFound {
+			continue
+		}
+		shards = append(shards, &Shard{Start: key[len(shardKeyPrefix):], GroupId: v.Vi64})
+	}
+	for i := 0; i < len(shards)-1; i++ {
+		shards[i].End = shards[i+1].Start
+	}
+
+	m, err := NewShardMap(c.shardMap().Groups, shards)
+	if err != nil {
+		return err
+	}
+
+	c.shardsMu.Lock()
+	c.Shards = m
+	c.shardsMu.Unlock()
+
+	c.log(LevelInfo, "KVClient: loaded shards", F("shards", len(shards)))
+	return nil
+}
+
+// reloadShards reloads Shards after a key is found moved.
+// The shard map may not be updated yet while a move is in progress, thus it
+// waits a while before reloading.
+func (c *KVClient) reloadShards() {
+	time.Sleep(10 * time.Millisecond)
+	if len(c.MetaAcceptorIds) == 0 {
+		return
+	}
+	if err := c.LoadShards(); err != nil {
+		c.log(LevelWarn, "KVClient: fail to load shards", F("err", err))
+	}
+}
+
+// SplitShard splits the shard containing key `at` into two at `at`, both served
+// by the same group as before. Thus no data is moved.
+func (c *KVClient) SplitShard(at string) error {
+
+	meta := c.metaClient()
+	for {
+		if err := c.LoadShards(); err != nil {
+			return err
+		}
+
+		shard := c.shardMap().Lookup(at)
+		if shard.Start == at {
+			return nil
+		}
+
+		ok, err := meta.CompareAndSet(shardKeyPrefix+at, -1, &Value{Vi64: shard.GroupId})
+		if err != nil {
+			return err
+		}
+		if ok {
+			return c.LoadShards()
+		}
+	}
+}
+
+// MoveShard moves the shard starting at `start` to group `groupId`:
+//
+// - Fence the range on a quorum of the source group, which stops any paxos on
+// it, and collect the instances and the compacted versions.
+//
+// - Unfence the range on every Acceptor of the target group, dropping anything
+// left by a previous move, and compact the versions compacted in the source
+// group: they are chosen tombstones, a reader reads them as such and a writer
+// writes after them.
+//
+// - For every instance not compacted, choose in the target group the committed value, or the
+// value with the highest VBal, just as phase-1 of paxos does. Since any chosen
+// value is voted by a quorum, which intersects the fenced quorum, every value
+// chosen in the source group is chosen again at the same version.
+//
+// - Choose the new group of the shard in the meta group.
+//
+// A client still sending requests to the source group is refused with
+// ShardMoved, reloads the shard map and runs the same instance on the target
+// group. Thus a write in progress is neither lost nor written twice.
+//
+// Only one MoveShard is allowed at a time. If it fails after fencing, the range
+// is unavailable until a MoveShard succeeds.
+func (c *KVClient) MoveShard(start string, groupId int64) error {
+
+	if err := c.LoadShards(); err != nil {
+		return err
+	}
+
+	m := c.shardMap()
+	shard := m.Lookup(start)
+	if shard.Start != start {
+		return fmt.Errorf("%w: no shard starts at %q", InvalidShardMap, start)
+	}
+	if shard.GroupId == groupId {
+		return nil
+	}
+
+	targetIds, found := m.Groups[groupId]
+	if !found {
+		return fmt.Errorf("%w: unknown group %d", InvalidShardMap, groupId)
+	}
+	sourceIds := m.Groups[shard.GroupId]
+
+	rng := &KeyRange{Start: shard.Start, End: shard.End}
+
+	instances, compacted, err := c.fenceAll(sourceIds, &FenceRequest{Range: rng}, len(sourceIds)/2+1)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = c.fenceAll(targetIds, &FenceRequest{Range: rng, Unfence: true, Compacted: compacted}, len(targetIds))
+	if err != nil {
+		return err
+	}
+
+	for _, inst := range maxVoted(instances) {
+		if floor, found := compacted[inst.Id.Key]; found && inst.Id.Ver <= floor {
+			continue
+		}
+		val := inst.Acceptor.Val
+		p, err := c.newProposer(inst.Id.Key, inst.Id.Ver)
+		if err != nil {
+			return err
+		}
+		v, _, err := p.runPaxos(targetIds, val, c.env(context.Background()))
+		if err != nil {
+			return err
+		}
+		if !proto.Equal(v, val) {
+			return fmt.Errorf("%s₍%d₎ is chosen with %v in group %d, expect: %v",
+				inst.Id.Key, inst.Id.Ver, v, groupId, val)
+		}
+	}
+
+	meta := c.metaClient()
+	_, ver, err := meta.Get(shardKeyPrefix + start)
+	if err != nil {
+		return err
+	}
+	ok, err := meta.CompareAndSet(shardKeyPrefix+start, ver, &Value{Vi64: groupId})
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("shard %q is changed by others while moving", start)
+	}
+
+	c.log(LevelInfo, "KVClient: moved shard", F("start", start), F("from", shard.GroupId), F("to", groupId))
+	return c.LoadShards()
+}
+
+// maxVoted returns, for every instance with a voted value, the committed one or
+// the one with the highest VBal, in key and version order.
+func maxVoted(instances []*Instance) []*Instance {
+
+	type instanceId struct {
+		key string
+		ver int64
+	}
+
+	best := map[instanceId]*Instance{}
+	for _, inst := range instances {
+		a := inst.Acceptor
+		if a.Val == nil {
+			continue
+		}
+
+		id := instanceId{inst.Id.Key, inst.Id.Ver}
+		b, found := best[id]
+		if !found || (!b.Acceptor.Committed && (a.Committed || a.VBal.GE(b.Acceptor.VBal))) {
+			best[id] = inst
+		}
+	}
+
+	res := make([]*Instance, 0, len(best))
+	for _, inst := range best {
+		res = append(res, inst)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		a, b := res[i].Id, res[j].Id
+		if a.Key != b.Key {
+			return a.Key < b.Key
+		}
+		return a.Ver < b.Ver
+	})
+	return res
+}
+
+// fenceAll sends a Fence request to Acceptors and returns the instances they
+// reply, and the highest compacted version of every key any of them replies.
+// It returns NotEnoughQuorum if less than `quorum` Acceptors succeed.
+func (c *KVClient) fenceAll(acceptorIds []int64, req *FenceRequest, quorum int) ([]*Instance, map[string]int64, error) {
+
+	ok := 0
+	instances := []*Instance{}
+	compacted := map[string]int64{}
+
+	for _, aid := range acceptorIds {
+		var reply *FenceReply
+		err := callAcceptor(aid, func(ctx context.Context, cli PaxosKVClient) (err error) {
+			reply, err = cli.Fence(ctx, req)
+			return err
+		})
+		if err != nil {
+			c.log(LevelWarn, "KVClient: Fence failure", F("acceptor", aid), F("err", err))
+			continue
+		}
+
+		ok++
+		instances = append(instances, reply.Instances...)
+		for key, floor := range reply.Compacted {
+			if f, found := compacted[key]; !found || f < floor {
+				compacted[key] = floor
+			}
+		}
+	}
+
+	if ok < quorum {
+		return nil, nil, NotEnoughQuorum
+	}
+	return instances, compacted, nil
+}
+
+// metaClient returns the client to access the shard map in MetaAcceptorIds.
+// It logs and traces as this client does, and takes ballots from this client,
+// thus it never reuses a ballot after a restart either.
+func (c *KVClient) metaClient() *KVClient {
+	c.shardsMu.Lock()
+	defer c.shardsMu.Unlock()
+
+	if c.meta == nil {
+		c.meta = &KVClient{
+			AcceptorIds: c.MetaAcceptorIds,
+			Logger:      c.Logger,
+			Tracer:      c.Tracer,
+			ProposerId:  c.ProposerId,
+			balOwner:    c,
+		}
+	}
+	return c.meta
+}
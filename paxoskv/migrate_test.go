@@ -0,0 +1,150 @@
+package paxoskv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestKVServer_Fence(t *testing.T) {
+
+	ta := require.New(t)
+
+	s := &KVServer{Storage: map[string]Versions{}}
+	for _, k := range []string{"a", "m", "x"} {
+		v, err := s.getLockedVersion(&PaxosInstanceId{Key: k})
+		ta.Nil(err)
+		v.acceptor.Val = &Value{Vi64: 1}
+		v.mu.Unlock()
+	}
+
+	reply, err := s.Fence(context.Background(), &FenceRequest{Range: &KeyRange{Start: "b", End: "y"}})
+	ta.Nil(err)
+	ta.Equal(2, len(reply.Instances))
+	ta.Equal("m", reply.Instances[0].Id.Key)
+	ta.Equal("x", reply.Instances[1].Id.Key)
+	ta.Equal(int64(1), reply.Instances[1].Acceptor.Val.Vi64)
+
+	_, err = s.getLockedVersion(&PaxosInstanceId{Key: "m"})
+	ta.Equal(codes.FailedPrecondition, status.Code(err))
+
+	v, err := s.getLockedVersion(&PaxosInstanceId{Key: "a"})
+	ta.Nil(err, "key out of the fenced range")
+	v.mu.Unlock()
+
+	// unfence a part of the fenced range
+	_, err = s.Fence(context.Background(), &FenceRequest{Range: &KeyRange{Start: "w", End: ""}, Unfence: true})
+	ta.Nil(err)
+	ta.Equal([]*KeyRange{{Start: "b", End: "w"}}, s.fences)
+
+	_, err = s.getLockedVersion(&PaxosInstanceId{Key: "m"})
+	ta.Equal(codes.FailedPrecondition, status.Code(err))
+
+	v, err = s.getLockedVersion(&PaxosInstanceId{Key: "x"})
+	ta.Nil(err)
+	ta.Nil(v.acceptor.Val, "unfenced instances are dropped")
+	v.mu.Unlock()
+}
+
+func TestKVClient_MoveShard(t *testing.T) {
+
+	ta := require.New(t)
+
+	groups := map[int64][]int64{
+		1: {0, 1, 2},
+		2: {3, 4, 5},
+	}
+	metaIds := []int64{6, 7, 8}
+
+	m, err := NewShardMap(groups, []*Shard{{GroupId: 1}})
+	ta.Nil(err)
+
+	servers, err := ServeAcceptors(append(m.AcceptorIds(), metaIds...))
+	ta.Nil(err)
+	defer func() {
+		for _, s := range servers {
+			s.Stop()
+		}
+	}()
+
+	c := &KVClient{Shards: m, MetaAcceptorIds: metaIds, ProposerId: 2}
+	ta.Nil(c.InitShards())
+
+	// a client that never reloads the shard map by itself
+	stale := &KVClient{Shards: m, MetaAcceptorIds: metaIds, ProposerId: 3}
+
+	for _, k := range []string{"a", "m", "x"} {
+		_, err := c.Set(k, &Value{Vi64: 1})
+		ta.Nil(err)
+	}
+
+	// a value voted by a quorum of group 1 but not committed.
+	p, err := c.newProposer("x", 1)
+	ta.Nil(err)
+	_, _, err = p.Phase1(groups[1], 2)
+	ta.Nil(err)
+	p.Val = &Value{Vi64: 2}
+	_, err = p.Phase2(groups[1], 2)
+	ta.Nil(err)
+
+	// y₀ and y₁ are compacted by GC, y₂ is written after them.
+	_, err = c.Set("y", &Value{Vi64: 1})
+	ta.Nil(err)
+	_, err = c.Delete("y")
+	ta.Nil(err)
+	for i := 0; i < 2; i++ {
+		for _, s := range servers {
+			s.KVServer.GC()
+		}
+	}
+	ver, err := c.Set("y", &Value{Vi64: 9})
+	ta.Nil(err)
+	ta.Equal(int64(2), ver)
+
+	ta.Nil(c.SplitShard("m"))
+	ta.Equal(2, len(c.shardMap().Shards))
+	ta.Equal(int64(1), c.shardMap().Lookup("x").GroupId)
+
+	ta.Nil(c.MoveShard("m", 2))
+	ta.Equal(int64(1), c.shardMap().Lookup("a").GroupId)
+	ta.Equal(int64(2), c.shardMap().Lookup("x").GroupId)
+
+	v, ver, err := c.Get("x")
+	ta.Nil(err)
+	ta.Equal(int64(1), ver, "the voted value is moved")
+	ta.Equal(int64(2), v.Vi64)
+
+	v, ver, err = c.Get("y")
+	ta.Nil(err, "the compacted versions are moved")
+	ta.Equal(int64(2), ver)
+	ta.Equal(int64(9), v.Vi64)
+
+	state := c.Inspect(&PaxosInstanceId{Key: "y", Ver: 1})
+	for _, aid := range groups[2] {
+		ta.Equal(AcceptorStatus_InstanceCompacted, state[aid].Status)
+	}
+
+	// the stale client is refused by group 1 and resumes on group 2.
+	ver, err = stale.Set("x", &Value{Vi64: 3})
+	ta.Nil(err)
+	ta.Equal(int64(2), ver)
+	ta.Equal(int64(2), stale.shardMap().Lookup("x").GroupId)
+
+	records, err := c.History("x", 0, -1)
+	ta.Nil(err)
+	ta.Equal(3, len(records))
+	ta.Equal(int64(3), records[2].Val.Vi64)
+
+	// moving back drops what group 1 left.
+	ta.Nil(c.MoveShard("m", 1))
+	records, err = c.History("x", 0, -1)
+	ta.Nil(err)
+	ta.Equal(3, len(records))
+
+	v, _, err = c.Get("a")
+	ta.Nil(err)
+	ta.Equal(int64(1), v.Vi64)
+}
@@ -18,7 +18,8 @@ func TestCase1SingleProposer(t *testing.T) {
 	acceptorIds := []int64{0, 1, 2}
 	quorum := 2
 
-	servers := ServeAcceptors(acceptorIds)
+	servers, err := ServeAcceptors(acceptorIds)
+	ta.Nil(err)
 	defer func() {
 		for _, s := range servers {
 			s.Stop()
@@ -66,7 +67,8 @@ func TestCase2DoubleProposer(t *testing.T) {
 	acceptorIds := []int64{0, 1, 2}
 	quorum := 2
 
-	servers := ServeAcceptors(acceptorIds)
+	servers, err := ServeAcceptors(acceptorIds)
+	ta.Nil(err)
 	defer func() {
 		for _, s := range servers {
 			s.Stop()
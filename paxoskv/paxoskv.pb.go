@@ -24,6 +24,72 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// AcceptorStatus is how an Acceptor handles a Prepare, Accept or Commit
+// request.
+type AcceptorStatus int32
+
+const (
+	// the ballot is promised, or the value is accepted or committed.
+	AcceptorStatus_Accepted AcceptorStatus = 0
+	// the Acceptor has seen a higher ballot, which is in LastBal.
+	AcceptorStatus_RejectedHigherBallot AcceptorStatus = 1
+	// the instance is compacted away by the Acceptor and can not be run any
+	// more. GC drops the versions of a key up to a committed tombstone, and
+	// the Acceptor keeps the highest dropped version to refuse every version
+	// up to it.
+	AcceptorStatus_InstanceCompacted AcceptorStatus = 2
+	// the Acceptor does not serve the key, e.g., the key is fenced for moving
+	// to another group.
+	AcceptorStatus_NotMember AcceptorStatus = 3
+	// the Acceptor fails to store its state; nothing is changed.
+	AcceptorStatus_StorageError AcceptorStatus = 4
+)
+
+// Enum value maps for AcceptorStatus.
+var (
+	AcceptorStatus_name = map[int32]string{
+		0: "Accepted",
+		1: "RejectedHigherBallot",
+		2: "InstanceCompacted",
+		3: "NotMember",
+		4: "StorageError",
+	}
+	AcceptorStatus_value = map[string]int32{
+		"Accepted":             0,
+		"RejectedHigherBallot": 1,
+		"InstanceCompacted":    2,
+		"NotMember":            3,
+		"StorageError":         4,
+	}
+)
+
+func (x AcceptorStatus) Enum() *AcceptorStatus {
+	p := new(AcceptorStatus)
+	*p = x
+	return p
+}
+
+func (x AcceptorStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (AcceptorStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_paxoskv_proto_enumTypes[0].Descriptor()
+}
+
+func (AcceptorStatus) Type() protoreflect.EnumType {
+	return &file_paxoskv_proto_enumTypes[0]
+}
+
+func (x AcceptorStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use AcceptorStatus.Descriptor instead.
+func (AcceptorStatus) EnumDescriptor() ([]byte, []int) {
+	return file_paxoskv_proto_rawDescGZIP(), []int{0}
+}
+
 // BallotNum is the ballot number in paxos. It consists of a monotonically
 // incremental number and a universally unique ProposerId.
 type BallotNum struct {
@@ -89,6 +155,20 @@ type Value struct {
 	unknownFields protoimpl.UnknownFields
 
 	Vi64 int64 `protobuf:"varint,1,opt,name=Vi64,proto3" json:"Vi64,omitempty"`
+	// Deleted marks this Value as a tombstone: the key is removed at this
+	// version. Vi64 is meaningless in a tombstone.
+	Deleted bool `protobuf:"varint,2,opt,name=Deleted,proto3" json:"Deleted,omitempty"`
+	// TxnId is set if this Value is written by a transaction.
+	// Such a Value is an intent: it is visible only after the transaction
+	// record of TxnId is chosen to be committed.
+	TxnId string `protobuf:"bytes,3,opt,name=TxnId,proto3" json:"TxnId,omitempty"`
+	// ExpireAt is the unix time in nanoseconds when this Value expires.
+	// 0 means never. An expired Value is replaced with a tombstone at the
+	// next version by the first reader that finds it expired.
+	ExpireAt int64 `protobuf:"varint,4,opt,name=ExpireAt,proto3" json:"ExpireAt,omitempty"`
+	// WriteId identifies the write proposing this Value, so that a writer
+	// tells its own Value from an equal one written by others.
+	WriteId string `protobuf:"bytes,5,opt,name=WriteId,proto3" json:"WriteId,omitempty"`
 }
 
 func (x *Value) Reset() {
@@ -130,6 +210,34 @@ func (x *Value) GetVi64() int64 {
 	return 0
 }
 
+func (x *Value) GetDeleted() bool {
+	if x != nil {
+		return x.Deleted
+	}
+	return false
+}
+
+func (x *Value) GetTxnId() string {
+	if x != nil {
+		return x.TxnId
+	}
+	return ""
+}
+
+func (x *Value) GetExpireAt() int64 {
+	if x != nil {
+		return x.ExpireAt
+	}
+	return 0
+}
+
+func (x *Value) GetWriteId() string {
+	if x != nil {
+		return x.WriteId
+	}
+	return ""
+}
+
 // PaxosInstanceId specifies what paxos instance it runs on.
 // A paxos instance is used to determine a specific version of a record.
 // E.g.: for a key-value record foo₀=0, to set foo=2, a paxos instance is
@@ -204,6 +312,11 @@ type Acceptor struct {
 	Val *Value `protobuf:"bytes,2,opt,name=Val,proto3" json:"Val,omitempty"`
 	// at which ballot number the Acceptor voted it.
 	VBal *BallotNum `protobuf:"bytes,3,opt,name=VBal,proto3" json:"VBal,omitempty"`
+	// whether Val is known to be chosen, by a Commit request.
+	// If it is, VBal is the ballot number at which Val is chosen.
+	Committed bool `protobuf:"varint,4,opt,name=Committed,proto3" json:"Committed,omitempty"`
+	// Status is how the request is handled, only in a reply.
+	Status AcceptorStatus `protobuf:"varint,5,opt,name=Status,proto3,enum=paxoskv.AcceptorStatus" json:"Status,omitempty"`
 }
 
 func (x *Acceptor) Reset() {
@@ -259,6 +372,20 @@ func (x *Acceptor) GetVBal() *BallotNum {
 	return nil
 }
 
+func (x *Acceptor) GetCommitted() bool {
+	if x != nil {
+		return x.Committed
+	}
+	return false
+}
+
+func (x *Acceptor) GetStatus() AcceptorStatus {
+	if x != nil {
+		return x.Status
+	}
+	return AcceptorStatus_Accepted
+}
+
 // Proposer is the state of a Proposer and also serves as the request of
 // Prepare/Accept.
 type Proposer struct {
@@ -327,268 +454,1504 @@ func (x *Proposer) GetVal() *Value {
 	return nil
 }
 
-var File_paxoskv_proto protoreflect.FileDescriptor
+// KeyRange specifies the keys in [Start, End).
+type KeyRange struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
 
-var file_paxoskv_proto_rawDesc = []byte{
-	0x0a, 0x0d, 0x70, 0x61, 0x78, 0x6f, 0x73, 0x6b, 0x76, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12,
-	0x07, 0x70, 0x61, 0x78, 0x6f, 0x73, 0x6b, 0x76, 0x22, 0x39, 0x0a, 0x09, 0x42, 0x61, 0x6c, 0x6c,
-	0x6f, 0x74, 0x4e, 0x75, 0x6d, 0x12, 0x0c, 0x0a, 0x01, 0x4e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03,
-	0x52, 0x01, 0x4e, 0x12, 0x1e, 0x0a, 0x0a, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x65, 0x72, 0x49,
-	0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x65,
-	0x72, 0x49, 0x64, 0x22, 0x1b, 0x0a, 0x05, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x12, 0x0a, 0x04,
-	0x56, 0x69, 0x36, 0x34, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x56, 0x69, 0x36, 0x34,
-	0x22, 0x35, 0x0a, 0x0f, 0x50, 0x61, 0x78, 0x6f, 0x73, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63,
-	0x65, 0x49, 0x64, 0x12, 0x10, 0x0a, 0x03, 0x4b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
-	0x52, 0x03, 0x4b, 0x65, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x56, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01,
-	0x28, 0x03, 0x52, 0x03, 0x56, 0x65, 0x72, 0x22, 0x82, 0x01, 0x0a, 0x08, 0x41, 0x63, 0x63, 0x65,
-	0x70, 0x74, 0x6f, 0x72, 0x12, 0x2c, 0x0a, 0x07, 0x4c, 0x61, 0x73, 0x74, 0x42, 0x61, 0x6c, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x70, 0x61, 0x78, 0x6f, 0x73, 0x6b, 0x76, 0x2e,
-	0x42, 0x61, 0x6c, 0x6c, 0x6f, 0x74, 0x4e, 0x75, 0x6d, 0x52, 0x07, 0x4c, 0x61, 0x73, 0x74, 0x42,
-	0x61, 0x6c, 0x12, 0x20, 0x0a, 0x03, 0x56, 0x61, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32,
-	0x0e, 0x2e, 0x70, 0x61, 0x78, 0x6f, 0x73, 0x6b, 0x76, 0x2e, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x52,
-	0x03, 0x56, 0x61, 0x6c, 0x12, 0x26, 0x0a, 0x04, 0x56, 0x42, 0x61, 0x6c, 0x18, 0x03, 0x20, 0x01,
-	0x28, 0x0b, 0x32, 0x12, 0x2e, 0x70, 0x61, 0x78, 0x6f, 0x73, 0x6b, 0x76, 0x2e, 0x42, 0x61, 0x6c,
-	0x6c, 0x6f, 0x74, 0x4e, 0x75, 0x6d, 0x52, 0x04, 0x56, 0x42, 0x61, 0x6c, 0x22, 0x7c, 0x0a, 0x08,
-	0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x65, 0x72, 0x12, 0x28, 0x0a, 0x02, 0x49, 0x64, 0x18, 0x01,
-	0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x70, 0x61, 0x78, 0x6f, 0x73, 0x6b, 0x76, 0x2e, 0x50,
-	0x61, 0x78, 0x6f, 0x73, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x49, 0x64, 0x52, 0x02,
-	0x49, 0x64, 0x12, 0x24, 0x0a, 0x03, 0x42, 0x61, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32,
-	0x12, 0x2e, 0x70, 0x61, 0x78, 0x6f, 0x73, 0x6b, 0x76, 0x2e, 0x42, 0x61, 0x6c, 0x6c, 0x6f, 0x74,
-	0x4e, 0x75, 0x6d, 0x52, 0x03, 0x42, 0x61, 0x6c, 0x12, 0x20, 0x0a, 0x03, 0x56, 0x61, 0x6c, 0x18,
-	0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x70, 0x61, 0x78, 0x6f, 0x73, 0x6b, 0x76, 0x2e,
-	0x56, 0x61, 0x6c, 0x75, 0x65, 0x52, 0x03, 0x56, 0x61, 0x6c, 0x32, 0x6e, 0x0a, 0x07, 0x50, 0x61,
-	0x78, 0x6f, 0x73, 0x4b, 0x56, 0x12, 0x31, 0x0a, 0x07, 0x50, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65,
-	0x12, 0x11, 0x2e, 0x70, 0x61, 0x78, 0x6f, 0x73, 0x6b, 0x76, 0x2e, 0x50, 0x72, 0x6f, 0x70, 0x6f,
-	0x73, 0x65, 0x72, 0x1a, 0x11, 0x2e, 0x70, 0x61, 0x78, 0x6f, 0x73, 0x6b, 0x76, 0x2e, 0x41, 0x63,
-	0x63, 0x65, 0x70, 0x74, 0x6f, 0x72, 0x22, 0x00, 0x12, 0x30, 0x0a, 0x06, 0x41, 0x63, 0x63, 0x65,
-	0x70, 0x74, 0x12, 0x11, 0x2e, 0x70, 0x61, 0x78, 0x6f, 0x73, 0x6b, 0x76, 0x2e, 0x50, 0x72, 0x6f,
-	0x70, 0x6f, 0x73, 0x65, 0x72, 0x1a, 0x11, 0x2e, 0x70, 0x61, 0x78, 0x6f, 0x73, 0x6b, 0x76, 0x2e,
-	0x41, 0x63, 0x63, 0x65, 0x70, 0x74, 0x6f, 0x72, 0x22, 0x00, 0x42, 0x1d, 0x5a, 0x1b, 0x67, 0x69,
-	0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6f, 0x70, 0x65, 0x6e, 0x61, 0x63, 0x69,
-	0x64, 0x2f, 0x70, 0x61, 0x78, 0x6f, 0x73, 0x6b, 0x76, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x33,
+	Start string `protobuf:"bytes,1,opt,name=Start,proto3" json:"Start,omitempty"`
+	// an empty End means no upper bound.
+	End string `protobuf:"bytes,2,opt,name=End,proto3" json:"End,omitempty"`
+	// the max number of keys to return. 0 means no limit.
+	Limit int64 `protobuf:"varint,3,opt,name=Limit,proto3" json:"Limit,omitempty"`
 }
 
-var (
-	file_paxoskv_proto_rawDescOnce sync.Once
-	file_paxoskv_proto_rawDescData = file_paxoskv_proto_rawDesc
-)
-
-func file_paxoskv_proto_rawDescGZIP() []byte {
-	file_paxoskv_proto_rawDescOnce.Do(func() {
-		file_paxoskv_proto_rawDescData = protoimpl.X.CompressGZIP(file_paxoskv_proto_rawDescData)
-	})
-	return file_paxoskv_proto_rawDescData
+func (x *KeyRange) Reset() {
+	*x = KeyRange{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_paxoskv_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
 }
 
-var file_paxoskv_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
-var file_paxoskv_proto_goTypes = []interface{}{
-	(*BallotNum)(nil),       // 0: paxoskv.BallotNum
-	(*Value)(nil),           // 1: paxoskv.Value
-	(*PaxosInstanceId)(nil), // 2: paxoskv.PaxosInstanceId
-	(*Acceptor)(nil),        // 3: paxoskv.Acceptor
-	(*Proposer)(nil),        // 4: paxoskv.Proposer
-}
-var file_paxoskv_proto_depIdxs = []int32{
-	0, // 0: paxoskv.Acceptor.LastBal:type_name -> paxoskv.BallotNum
-	1, // 1: paxoskv.Acceptor.Val:type_name -> paxoskv.Value
-	0, // 2: paxoskv.Acceptor.VBal:type_name -> paxoskv.BallotNum
-	2, // 3: paxoskv.Proposer.Id:type_name -> paxoskv.PaxosInstanceId
-	0, // 4: paxoskv.Proposer.Bal:type_name -> paxoskv.BallotNum
-	1, // 5: paxoskv.Proposer.Val:type_name -> paxoskv.Value
-	4, // 6: paxoskv.PaxosKV.Prepare:input_type -> paxoskv.Proposer
-	4, // 7: paxoskv.PaxosKV.Accept:input_type -> paxoskv.Proposer
-	3, // 8: paxoskv.PaxosKV.Prepare:output_type -> paxoskv.Acceptor
-	3, // 9: paxoskv.PaxosKV.Accept:output_type -> paxoskv.Acceptor
-	8, // [8:10] is the sub-list for method output_type
-	6, // [6:8] is the sub-list for method input_type
-	6, // [6:6] is the sub-list for extension type_name
-	6, // [6:6] is the sub-list for extension extendee
-	0, // [0:6] is the sub-list for field type_name
+func (x *KeyRange) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-func init() { file_paxoskv_proto_init() }
-func file_paxoskv_proto_init() {
-	if File_paxoskv_proto != nil {
-		return
-	}
-	if !protoimpl.UnsafeEnabled {
-		file_paxoskv_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*BallotNum); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-		file_paxoskv_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Value); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-		file_paxoskv_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*PaxosInstanceId); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-		file_paxoskv_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Acceptor); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-		file_paxoskv_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Proposer); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
+func (*KeyRange) ProtoMessage() {}
+
+func (x *KeyRange) ProtoReflect() protoreflect.Message {
+	mi := &file_paxoskv_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
 		}
+		return ms
 	}
-	type x struct{}
-	out := protoimpl.TypeBuilder{
-		File: protoimpl.DescBuilder{
-			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
-			RawDescriptor: file_paxoskv_proto_rawDesc,
-			NumEnums:      0,
-			NumMessages:   5,
-			NumExtensions: 0,
-			NumServices:   1,
-		},
-		GoTypes:           file_paxoskv_proto_goTypes,
-		DependencyIndexes: file_paxoskv_proto_depIdxs,
-		MessageInfos:      file_paxoskv_proto_msgTypes,
-	}.Build()
-	File_paxoskv_proto = out.File
-	file_paxoskv_proto_rawDesc = nil
-	file_paxoskv_proto_goTypes = nil
-	file_paxoskv_proto_depIdxs = nil
+	return mi.MessageOf(x)
 }
 
-// Reference imports to suppress errors if they are not otherwise used.
-var _ context.Context
-var _ grpc.ClientConnInterface
+// Deprecated: Use KeyRange.ProtoReflect.Descriptor instead.
+func (*KeyRange) Descriptor() ([]byte, []int) {
+	return file_paxoskv_proto_rawDescGZIP(), []int{5}
+}
 
-// This is a compile-time assertion to ensure that this generated file
-// is compatible with the grpc package it is being compiled against.
-const _ = grpc.SupportPackageIsVersion6
+func (x *KeyRange) GetStart() string {
+	if x != nil {
+		return x.Start
+	}
+	return ""
+}
 
-// PaxosKVClient is the client API for PaxosKV service.
-//
-// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
-type PaxosKVClient interface {
-	Prepare(ctx context.Context, in *Proposer, opts ...grpc.CallOption) (*Acceptor, error)
-	Accept(ctx context.Context, in *Proposer, opts ...grpc.CallOption) (*Acceptor, error)
+func (x *KeyRange) GetEnd() string {
+	if x != nil {
+		return x.End
+	}
+	return ""
 }
 
-type paxosKVClient struct {
-	cc grpc.ClientConnInterface
+func (x *KeyRange) GetLimit() int64 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
 }
 
-func NewPaxosKVClient(cc grpc.ClientConnInterface) PaxosKVClient {
-	return &paxosKVClient{cc}
+// KeyList is the reply of Keys.
+type KeyList struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Keys []string `protobuf:"bytes,1,rep,name=Keys,proto3" json:"Keys,omitempty"`
 }
 
-func (c *paxosKVClient) Prepare(ctx context.Context, in *Proposer, opts ...grpc.CallOption) (*Acceptor, error) {
-	out := new(Acceptor)
-	err := c.cc.Invoke(ctx, "/paxoskv.PaxosKV/Prepare", in, out, opts...)
-	if err != nil {
-		return nil, err
+func (x *KeyList) Reset() {
+	*x = KeyList{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_paxoskv_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
 	}
-	return out, nil
 }
 
-func (c *paxosKVClient) Accept(ctx context.Context, in *Proposer, opts ...grpc.CallOption) (*Acceptor, error) {
-	out := new(Acceptor)
-	err := c.cc.Invoke(ctx, "/paxoskv.PaxosKV/Accept", in, out, opts...)
-	if err != nil {
-		return nil, err
+func (x *KeyList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeyList) ProtoMessage() {}
+
+func (x *KeyList) ProtoReflect() protoreflect.Message {
+	mi := &file_paxoskv_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return out, nil
+	return mi.MessageOf(x)
 }
 
-// PaxosKVServer is the server API for PaxosKV service.
-type PaxosKVServer interface {
-	Prepare(context.Context, *Proposer) (*Acceptor, error)
-	Accept(context.Context, *Proposer) (*Acceptor, error)
+// Deprecated: Use KeyList.ProtoReflect.Descriptor instead.
+func (*KeyList) Descriptor() ([]byte, []int) {
+	return file_paxoskv_proto_rawDescGZIP(), []int{6}
 }
 
-// UnimplementedPaxosKVServer can be embedded to have forward compatible implementations.
-type UnimplementedPaxosKVServer struct {
+func (x *KeyList) GetKeys() []string {
+	if x != nil {
+		return x.Keys
+	}
+	return nil
 }
 
-func (*UnimplementedPaxosKVServer) Prepare(context.Context, *Proposer) (*Acceptor, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Prepare not implemented")
+// Record is a version of a key-value record.
+type Record struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Key string `protobuf:"bytes,1,opt,name=Key,proto3" json:"Key,omitempty"`
+	Ver int64  `protobuf:"varint,2,opt,name=Ver,proto3" json:"Ver,omitempty"`
+	Val *Value `protobuf:"bytes,3,opt,name=Val,proto3" json:"Val,omitempty"`
+	// a ballot number at which Val is chosen. A value chosen by concurrent
+	// proposers may be chosen at several ballots.
+	Bal *BallotNum `protobuf:"bytes,4,opt,name=Bal,proto3" json:"Bal,omitempty"`
 }
-func (*UnimplementedPaxosKVServer) Accept(context.Context, *Proposer) (*Acceptor, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Accept not implemented")
+
+func (x *Record) Reset() {
+	*x = Record{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_paxoskv_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
 }
 
-func RegisterPaxosKVServer(s *grpc.Server, srv PaxosKVServer) {
-	s.RegisterService(&_PaxosKV_serviceDesc, srv)
+func (x *Record) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-func _PaxosKV_Prepare_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(Proposer)
-	if err := dec(in); err != nil {
-		return nil, err
-	}
-	if interceptor == nil {
-		return srv.(PaxosKVServer).Prepare(ctx, in)
+func (*Record) ProtoMessage() {}
+
+func (x *Record) ProtoReflect() protoreflect.Message {
+	mi := &file_paxoskv_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Record.ProtoReflect.Descriptor instead.
+func (*Record) Descriptor() ([]byte, []int) {
+	return file_paxoskv_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Record) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *Record) GetVer() int64 {
+	if x != nil {
+		return x.Ver
+	}
+	return 0
+}
+
+func (x *Record) GetVal() *Value {
+	if x != nil {
+		return x.Val
+	}
+	return nil
+}
+
+func (x *Record) GetBal() *BallotNum {
+	if x != nil {
+		return x.Bal
+	}
+	return nil
+}
+
+// WatchRequest specifies what versions to watch.
+type WatchRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// the key to watch, or the key prefix to watch if Prefix is true.
+	Key    string `protobuf:"bytes,1,opt,name=Key,proto3" json:"Key,omitempty"`
+	Prefix bool   `protobuf:"varint,2,opt,name=Prefix,proto3" json:"Prefix,omitempty"`
+	// watch versions since FromVer.
+	FromVer int64 `protobuf:"varint,3,opt,name=FromVer,proto3" json:"FromVer,omitempty"`
+}
+
+func (x *WatchRequest) Reset() {
+	*x = WatchRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_paxoskv_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchRequest) ProtoMessage() {}
+
+func (x *WatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_paxoskv_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRequest.ProtoReflect.Descriptor instead.
+func (*WatchRequest) Descriptor() ([]byte, []int) {
+	return file_paxoskv_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *WatchRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *WatchRequest) GetPrefix() bool {
+	if x != nil {
+		return x.Prefix
+	}
+	return false
+}
+
+func (x *WatchRequest) GetFromVer() int64 {
+	if x != nil {
+		return x.FromVer
+	}
+	return 0
+}
+
+// FenceRequest specifies the range to fence or unfence.
+type FenceRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Range   *KeyRange `protobuf:"bytes,1,opt,name=Range,proto3" json:"Range,omitempty"`
+	Unfence bool      `protobuf:"varint,2,opt,name=Unfence,proto3" json:"Unfence,omitempty"`
+	// with Unfence, the highest compacted version of keys in the range, to
+	// refuse from now on, as if they were compacted by this Acceptor.
+	Compacted map[string]int64 `protobuf:"bytes,3,rep,name=Compacted,proto3" json:"Compacted,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+}
+
+func (x *FenceRequest) Reset() {
+	*x = FenceRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_paxoskv_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FenceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FenceRequest) ProtoMessage() {}
+
+func (x *FenceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_paxoskv_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FenceRequest.ProtoReflect.Descriptor instead.
+func (*FenceRequest) Descriptor() ([]byte, []int) {
+	return file_paxoskv_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *FenceRequest) GetRange() *KeyRange {
+	if x != nil {
+		return x.Range
+	}
+	return nil
+}
+
+func (x *FenceRequest) GetUnfence() bool {
+	if x != nil {
+		return x.Unfence
+	}
+	return false
+}
+
+func (x *FenceRequest) GetCompacted() map[string]int64 {
+	if x != nil {
+		return x.Compacted
+	}
+	return nil
+}
+
+// Instance is the state of an Acceptor of a paxos instance.
+type Instance struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id       *PaxosInstanceId `protobuf:"bytes,1,opt,name=Id,proto3" json:"Id,omitempty"`
+	Acceptor *Acceptor        `protobuf:"bytes,2,opt,name=Acceptor,proto3" json:"Acceptor,omitempty"`
+}
+
+func (x *Instance) Reset() {
+	*x = Instance{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_paxoskv_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Instance) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Instance) ProtoMessage() {}
+
+func (x *Instance) ProtoReflect() protoreflect.Message {
+	mi := &file_paxoskv_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Instance.ProtoReflect.Descriptor instead.
+func (*Instance) Descriptor() ([]byte, []int) {
+	return file_paxoskv_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Instance) GetId() *PaxosInstanceId {
+	if x != nil {
+		return x.Id
+	}
+	return nil
+}
+
+func (x *Instance) GetAcceptor() *Acceptor {
+	if x != nil {
+		return x.Acceptor
+	}
+	return nil
+}
+
+// FenceReply is the reply of Fence.
+type FenceReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// the instances in the fenced range, in key and version order.
+	Instances []*Instance `protobuf:"bytes,1,rep,name=Instances,proto3" json:"Instances,omitempty"`
+	// the highest compacted version of keys in the fenced range.
+	Compacted map[string]int64 `protobuf:"bytes,2,rep,name=Compacted,proto3" json:"Compacted,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+}
+
+func (x *FenceReply) Reset() {
+	*x = FenceReply{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_paxoskv_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FenceReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FenceReply) ProtoMessage() {}
+
+func (x *FenceReply) ProtoReflect() protoreflect.Message {
+	mi := &file_paxoskv_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FenceReply.ProtoReflect.Descriptor instead.
+func (*FenceReply) Descriptor() ([]byte, []int) {
+	return file_paxoskv_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *FenceReply) GetInstances() []*Instance {
+	if x != nil {
+		return x.Instances
+	}
+	return nil
+}
+
+func (x *FenceReply) GetCompacted() map[string]int64 {
+	if x != nil {
+		return x.Compacted
+	}
+	return nil
+}
+
+// LogEntry is a record in the write-ahead log of an Acceptor.
+// Only one of the fields is set.
+type LogEntry struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// the new state of an instance.
+	Instance *Instance `protobuf:"bytes,1,opt,name=Instance,proto3" json:"Instance,omitempty"`
+	// drop all versions of Drop.Key.
+	Drop *PaxosInstanceId `protobuf:"bytes,2,opt,name=Drop,proto3" json:"Drop,omitempty"`
+	// a range is fenced, or unfenced.
+	Fence *FenceRequest `protobuf:"bytes,3,opt,name=Fence,proto3" json:"Fence,omitempty"`
+	// drop versions of Compact.Key up to Compact.Ver, and refuse them from
+	// now on.
+	Compact *PaxosInstanceId `protobuf:"bytes,4,opt,name=Compact,proto3" json:"Compact,omitempty"`
+}
+
+func (x *LogEntry) Reset() {
+	*x = LogEntry{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_paxoskv_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LogEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogEntry) ProtoMessage() {}
+
+func (x *LogEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_paxoskv_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogEntry.ProtoReflect.Descriptor instead.
+func (*LogEntry) Descriptor() ([]byte, []int) {
+	return file_paxoskv_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *LogEntry) GetInstance() *Instance {
+	if x != nil {
+		return x.Instance
+	}
+	return nil
+}
+
+func (x *LogEntry) GetDrop() *PaxosInstanceId {
+	if x != nil {
+		return x.Drop
+	}
+	return nil
+}
+
+func (x *LogEntry) GetFence() *FenceRequest {
+	if x != nil {
+		return x.Fence
+	}
+	return nil
+}
+
+func (x *LogEntry) GetCompact() *PaxosInstanceId {
+	if x != nil {
+		return x.Compact
+	}
+	return nil
+}
+
+// RecordList is the reply of Scan.
+type RecordList struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Records []*Record `protobuf:"bytes,1,rep,name=Records,proto3" json:"Records,omitempty"`
+}
+
+func (x *RecordList) Reset() {
+	*x = RecordList{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_paxoskv_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RecordList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecordList) ProtoMessage() {}
+
+func (x *RecordList) ProtoReflect() protoreflect.Message {
+	mi := &file_paxoskv_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecordList.ProtoReflect.Descriptor instead.
+func (*RecordList) Descriptor() ([]byte, []int) {
+	return file_paxoskv_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *RecordList) GetRecords() []*Record {
+	if x != nil {
+		return x.Records
+	}
+	return nil
+}
+
+// StatsRequest is the request of Stats.
+type StatsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *StatsRequest) Reset() {
+	*x = StatsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_paxoskv_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatsRequest) ProtoMessage() {}
+
+func (x *StatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_paxoskv_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatsRequest.ProtoReflect.Descriptor instead.
+func (*StatsRequest) Descriptor() ([]byte, []int) {
+	return file_paxoskv_proto_rawDescGZIP(), []int{14}
+}
+
+// AcceptorStats is the reply of Stats.
+type AcceptorStats struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id        int64 `protobuf:"varint,1,opt,name=Id,proto3" json:"Id,omitempty"`
+	Keys      int64 `protobuf:"varint,2,opt,name=Keys,proto3" json:"Keys,omitempty"`
+	Instances int64 `protobuf:"varint,3,opt,name=Instances,proto3" json:"Instances,omitempty"`
+	// the instances with a chosen value learnt by Commit.
+	Committed int64 `protobuf:"varint,4,opt,name=Committed,proto3" json:"Committed,omitempty"`
+	// the number of fenced key ranges.
+	Fences   int64 `protobuf:"varint,5,opt,name=Fences,proto3" json:"Fences,omitempty"`
+	Watchers int64 `protobuf:"varint,6,opt,name=Watchers,proto3" json:"Watchers,omitempty"`
+}
+
+func (x *AcceptorStats) Reset() {
+	*x = AcceptorStats{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_paxoskv_proto_msgTypes[15]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AcceptorStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AcceptorStats) ProtoMessage() {}
+
+func (x *AcceptorStats) ProtoReflect() protoreflect.Message {
+	mi := &file_paxoskv_proto_msgTypes[15]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AcceptorStats.ProtoReflect.Descriptor instead.
+func (*AcceptorStats) Descriptor() ([]byte, []int) {
+	return file_paxoskv_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *AcceptorStats) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *AcceptorStats) GetKeys() int64 {
+	if x != nil {
+		return x.Keys
+	}
+	return 0
+}
+
+func (x *AcceptorStats) GetInstances() int64 {
+	if x != nil {
+		return x.Instances
+	}
+	return 0
+}
+
+func (x *AcceptorStats) GetCommitted() int64 {
+	if x != nil {
+		return x.Committed
+	}
+	return 0
+}
+
+func (x *AcceptorStats) GetFences() int64 {
+	if x != nil {
+		return x.Fences
+	}
+	return 0
+}
+
+func (x *AcceptorStats) GetWatchers() int64 {
+	if x != nil {
+		return x.Watchers
+	}
+	return 0
+}
+
+var File_paxoskv_proto protoreflect.FileDescriptor
+
+var file_paxoskv_proto_rawDesc = []byte{
+	0x0a, 0x0d, 0x70, 0x61, 0x78, 0x6f, 0x73, 0x6b, 0x76, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12,
+	0x07, 0x70, 0x61, 0x78, 0x6f, 0x73, 0x6b, 0x76, 0x22, 0x39, 0x0a, 0x09, 0x42, 0x61, 0x6c, 0x6c,
+	0x6f, 0x74, 0x4e, 0x75, 0x6d, 0x12, 0x0c, 0x0a, 0x01, 0x4e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x01, 0x4e, 0x12, 0x1e, 0x0a, 0x0a, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x65, 0x72, 0x49,
+	0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x65,
+	0x72, 0x49, 0x64, 0x22, 0x81, 0x01, 0x0a, 0x05, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x12, 0x0a,
+	0x04, 0x56, 0x69, 0x36, 0x34, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x56, 0x69, 0x36,
+	0x34, 0x12, 0x18, 0x0a, 0x07, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x64, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x07, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x54,
+	0x78, 0x6e, 0x49, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x54, 0x78, 0x6e, 0x49,
+	0x64, 0x12, 0x1a, 0x0a, 0x08, 0x45, 0x78, 0x70, 0x69, 0x72, 0x65, 0x41, 0x74, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x08, 0x45, 0x78, 0x70, 0x69, 0x72, 0x65, 0x41, 0x74, 0x12, 0x18, 0x0a,
+	0x07, 0x57, 0x72, 0x69, 0x74, 0x65, 0x49, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07,
+	0x57, 0x72, 0x69, 0x74, 0x65, 0x49, 0x64, 0x22, 0x35, 0x0a, 0x0f, 0x50, 0x61, 0x78, 0x6f, 0x73,
+	0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x49, 0x64, 0x12, 0x10, 0x0a, 0x03, 0x4b, 0x65,
+	0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x4b, 0x65, 0x79, 0x12, 0x10, 0x0a, 0x03,
+	0x56, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x03, 0x56, 0x65, 0x72, 0x22, 0xd1,
+	0x01, 0x0a, 0x08, 0x41, 0x63, 0x63, 0x65, 0x70, 0x74, 0x6f, 0x72, 0x12, 0x2c, 0x0a, 0x07, 0x4c,
+	0x61, 0x73, 0x74, 0x42, 0x61, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x70,
+	0x61, 0x78, 0x6f, 0x73, 0x6b, 0x76, 0x2e, 0x42, 0x61, 0x6c, 0x6c, 0x6f, 0x74, 0x4e, 0x75, 0x6d,
+	0x52, 0x07, 0x4c, 0x61, 0x73, 0x74, 0x42, 0x61, 0x6c, 0x12, 0x20, 0x0a, 0x03, 0x56, 0x61, 0x6c,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x70, 0x61, 0x78, 0x6f, 0x73, 0x6b, 0x76,
+	0x2e, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x52, 0x03, 0x56, 0x61, 0x6c, 0x12, 0x26, 0x0a, 0x04, 0x56,
+	0x42, 0x61, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x70, 0x61, 0x78, 0x6f,
+	0x73, 0x6b, 0x76, 0x2e, 0x42, 0x61, 0x6c, 0x6c, 0x6f, 0x74, 0x4e, 0x75, 0x6d, 0x52, 0x04, 0x56,
+	0x42, 0x61, 0x6c, 0x12, 0x1c, 0x0a, 0x09, 0x43, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x74, 0x65, 0x64,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x43, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x74, 0x65,
+	0x64, 0x12, 0x2f, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x0e, 0x32, 0x17, 0x2e, 0x70, 0x61, 0x78, 0x6f, 0x73, 0x6b, 0x76, 0x2e, 0x41, 0x63, 0x63, 0x65,
+	0x70, 0x74, 0x6f, 0x72, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06, 0x53, 0x74, 0x61, 0x74,
+	0x75, 0x73, 0x22, 0x7c, 0x0a, 0x08, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x65, 0x72, 0x12, 0x28,
+	0x0a, 0x02, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x70, 0x61, 0x78,
+	0x6f, 0x73, 0x6b, 0x76, 0x2e, 0x50, 0x61, 0x78, 0x6f, 0x73, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e,
+	0x63, 0x65, 0x49, 0x64, 0x52, 0x02, 0x49, 0x64, 0x12, 0x24, 0x0a, 0x03, 0x42, 0x61, 0x6c, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x70, 0x61, 0x78, 0x6f, 0x73, 0x6b, 0x76, 0x2e,
+	0x42, 0x61, 0x6c, 0x6c, 0x6f, 0x74, 0x4e, 0x75, 0x6d, 0x52, 0x03, 0x42, 0x61, 0x6c, 0x12, 0x20,
+	0x0a, 0x03, 0x56, 0x61, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x70, 0x61,
+	0x78, 0x6f, 0x73, 0x6b, 0x76, 0x2e, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x52, 0x03, 0x56, 0x61, 0x6c,
+	0x22, 0x48, 0x0a, 0x08, 0x4b, 0x65, 0x79, 0x52, 0x61, 0x6e, 0x67, 0x65, 0x12, 0x14, 0x0a, 0x05,
+	0x53, 0x74, 0x61, 0x72, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x53, 0x74, 0x61,
+	0x72, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x45, 0x6e, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x03, 0x45, 0x6e, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x05, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x22, 0x1d, 0x0a, 0x07, 0x4b, 0x65,
+	0x79, 0x4c, 0x69, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x4b, 0x65, 0x79, 0x73, 0x18, 0x01, 0x20,
+	0x03, 0x28, 0x09, 0x52, 0x04, 0x4b, 0x65, 0x79, 0x73, 0x22, 0x74, 0x0a, 0x06, 0x52, 0x65, 0x63,
+	0x6f, 0x72, 0x64, 0x12, 0x10, 0x0a, 0x03, 0x4b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x03, 0x4b, 0x65, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x56, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x03, 0x56, 0x65, 0x72, 0x12, 0x20, 0x0a, 0x03, 0x56, 0x61, 0x6c, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x70, 0x61, 0x78, 0x6f, 0x73, 0x6b, 0x76, 0x2e, 0x56,
+	0x61, 0x6c, 0x75, 0x65, 0x52, 0x03, 0x56, 0x61, 0x6c, 0x12, 0x24, 0x0a, 0x03, 0x42, 0x61, 0x6c,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x70, 0x61, 0x78, 0x6f, 0x73, 0x6b, 0x76,
+	0x2e, 0x42, 0x61, 0x6c, 0x6c, 0x6f, 0x74, 0x4e, 0x75, 0x6d, 0x52, 0x03, 0x42, 0x61, 0x6c, 0x22,
+	0x52, 0x0a, 0x0c, 0x57, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
+	0x10, 0x0a, 0x03, 0x4b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x4b, 0x65,
+	0x79, 0x12, 0x16, 0x0a, 0x06, 0x50, 0x72, 0x65, 0x66, 0x69, 0x78, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x06, 0x50, 0x72, 0x65, 0x66, 0x69, 0x78, 0x12, 0x18, 0x0a, 0x07, 0x46, 0x72, 0x6f,
+	0x6d, 0x56, 0x65, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x46, 0x72, 0x6f, 0x6d,
+	0x56, 0x65, 0x72, 0x22, 0xd3, 0x01, 0x0a, 0x0c, 0x46, 0x65, 0x6e, 0x63, 0x65, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x12, 0x27, 0x0a, 0x05, 0x52, 0x61, 0x6e, 0x67, 0x65, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x70, 0x61, 0x78, 0x6f, 0x73, 0x6b, 0x76, 0x2e, 0x4b, 0x65,
+	0x79, 0x52, 0x61, 0x6e, 0x67, 0x65, 0x52, 0x05, 0x52, 0x61, 0x6e, 0x67, 0x65, 0x12, 0x18, 0x0a,
+	0x07, 0x55, 0x6e, 0x66, 0x65, 0x6e, 0x63, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07,
+	0x55, 0x6e, 0x66, 0x65, 0x6e, 0x63, 0x65, 0x12, 0x42, 0x0a, 0x09, 0x43, 0x6f, 0x6d, 0x70, 0x61,
+	0x63, 0x74, 0x65, 0x64, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x24, 0x2e, 0x70, 0x61, 0x78,
+	0x6f, 0x73, 0x6b, 0x76, 0x2e, 0x46, 0x65, 0x6e, 0x63, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x2e, 0x43, 0x6f, 0x6d, 0x70, 0x61, 0x63, 0x74, 0x65, 0x64, 0x45, 0x6e, 0x74, 0x72, 0x79,
+	0x52, 0x09, 0x43, 0x6f, 0x6d, 0x70, 0x61, 0x63, 0x74, 0x65, 0x64, 0x1a, 0x3c, 0x0a, 0x0e, 0x43,
+	0x6f, 0x6d, 0x70, 0x61, 0x63, 0x74, 0x65, 0x64, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a,
+	0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12,
+	0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0x63, 0x0a, 0x08, 0x49, 0x6e, 0x73,
+	0x74, 0x61, 0x6e, 0x63, 0x65, 0x12, 0x28, 0x0a, 0x02, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x18, 0x2e, 0x70, 0x61, 0x78, 0x6f, 0x73, 0x6b, 0x76, 0x2e, 0x50, 0x61, 0x78, 0x6f,
+	0x73, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x49, 0x64, 0x52, 0x02, 0x49, 0x64, 0x12,
+	0x2d, 0x0a, 0x08, 0x41, 0x63, 0x63, 0x65, 0x70, 0x74, 0x6f, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x11, 0x2e, 0x70, 0x61, 0x78, 0x6f, 0x73, 0x6b, 0x76, 0x2e, 0x41, 0x63, 0x63, 0x65,
+	0x70, 0x74, 0x6f, 0x72, 0x52, 0x08, 0x41, 0x63, 0x63, 0x65, 0x70, 0x74, 0x6f, 0x72, 0x22, 0xbd,
+	0x01, 0x0a, 0x0a, 0x46, 0x65, 0x6e, 0x63, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x2f, 0x0a,
+	0x09, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x11, 0x2e, 0x70, 0x61, 0x78, 0x6f, 0x73, 0x6b, 0x76, 0x2e, 0x49, 0x6e, 0x73, 0x74, 0x61,
+	0x6e, 0x63, 0x65, 0x52, 0x09, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x73, 0x12, 0x40,
+	0x0a, 0x09, 0x43, 0x6f, 0x6d, 0x70, 0x61, 0x63, 0x74, 0x65, 0x64, 0x18, 0x02, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x22, 0x2e, 0x70, 0x61, 0x78, 0x6f, 0x73, 0x6b, 0x76, 0x2e, 0x46, 0x65, 0x6e, 0x63,
+	0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x2e, 0x43, 0x6f, 0x6d, 0x70, 0x61, 0x63, 0x74, 0x65, 0x64,
+	0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x09, 0x43, 0x6f, 0x6d, 0x70, 0x61, 0x63, 0x74, 0x65, 0x64,
+	0x1a, 0x3c, 0x0a, 0x0e, 0x43, 0x6f, 0x6d, 0x70, 0x61, 0x63, 0x74, 0x65, 0x64, 0x45, 0x6e, 0x74,
+	0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0xc8,
+	0x01, 0x0a, 0x08, 0x4c, 0x6f, 0x67, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x2d, 0x0a, 0x08, 0x49,
+	0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e,
+	0x70, 0x61, 0x78, 0x6f, 0x73, 0x6b, 0x76, 0x2e, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65,
+	0x52, 0x08, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x12, 0x2c, 0x0a, 0x04, 0x44, 0x72,
+	0x6f, 0x70, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x70, 0x61, 0x78, 0x6f, 0x73,
+	0x6b, 0x76, 0x2e, 0x50, 0x61, 0x78, 0x6f, 0x73, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65,
+	0x49, 0x64, 0x52, 0x04, 0x44, 0x72, 0x6f, 0x70, 0x12, 0x2b, 0x0a, 0x05, 0x46, 0x65, 0x6e, 0x63,
+	0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x70, 0x61, 0x78, 0x6f, 0x73, 0x6b,
+	0x76, 0x2e, 0x46, 0x65, 0x6e, 0x63, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x52, 0x05,
+	0x46, 0x65, 0x6e, 0x63, 0x65, 0x12, 0x32, 0x0a, 0x07, 0x43, 0x6f, 0x6d, 0x70, 0x61, 0x63, 0x74,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x70, 0x61, 0x78, 0x6f, 0x73, 0x6b, 0x76,
+	0x2e, 0x50, 0x61, 0x78, 0x6f, 0x73, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x49, 0x64,
+	0x52, 0x07, 0x43, 0x6f, 0x6d, 0x70, 0x61, 0x63, 0x74, 0x22, 0x37, 0x0a, 0x0a, 0x52, 0x65, 0x63,
+	0x6f, 0x72, 0x64, 0x4c, 0x69, 0x73, 0x74, 0x12, 0x29, 0x0a, 0x07, 0x52, 0x65, 0x63, 0x6f, 0x72,
+	0x64, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x70, 0x61, 0x78, 0x6f, 0x73,
+	0x6b, 0x76, 0x2e, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x52, 0x07, 0x52, 0x65, 0x63, 0x6f, 0x72,
+	0x64, 0x73, 0x22, 0x0e, 0x0a, 0x0c, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x22, 0xa3, 0x01, 0x0a, 0x0d, 0x41, 0x63, 0x63, 0x65, 0x70, 0x74, 0x6f, 0x72, 0x53,
+	0x74, 0x61, 0x74, 0x73, 0x12, 0x0e, 0x0a, 0x02, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x02, 0x49, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x4b, 0x65, 0x79, 0x73, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x04, 0x4b, 0x65, 0x79, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x49, 0x6e, 0x73, 0x74,
+	0x61, 0x6e, 0x63, 0x65, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x49, 0x6e, 0x73,
+	0x74, 0x61, 0x6e, 0x63, 0x65, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x43, 0x6f, 0x6d, 0x6d, 0x69, 0x74,
+	0x74, 0x65, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x43, 0x6f, 0x6d, 0x6d, 0x69,
+	0x74, 0x74, 0x65, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x46, 0x65, 0x6e, 0x63, 0x65, 0x73, 0x18, 0x05,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x46, 0x65, 0x6e, 0x63, 0x65, 0x73, 0x12, 0x1a, 0x0a, 0x08,
+	0x57, 0x61, 0x74, 0x63, 0x68, 0x65, 0x72, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08,
+	0x57, 0x61, 0x74, 0x63, 0x68, 0x65, 0x72, 0x73, 0x2a, 0x70, 0x0a, 0x0e, 0x41, 0x63, 0x63, 0x65,
+	0x70, 0x74, 0x6f, 0x72, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x0c, 0x0a, 0x08, 0x41, 0x63,
+	0x63, 0x65, 0x70, 0x74, 0x65, 0x64, 0x10, 0x00, 0x12, 0x18, 0x0a, 0x14, 0x52, 0x65, 0x6a, 0x65,
+	0x63, 0x74, 0x65, 0x64, 0x48, 0x69, 0x67, 0x68, 0x65, 0x72, 0x42, 0x61, 0x6c, 0x6c, 0x6f, 0x74,
+	0x10, 0x01, 0x12, 0x15, 0x0a, 0x11, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x43, 0x6f,
+	0x6d, 0x70, 0x61, 0x63, 0x74, 0x65, 0x64, 0x10, 0x02, 0x12, 0x0d, 0x0a, 0x09, 0x4e, 0x6f, 0x74,
+	0x4d, 0x65, 0x6d, 0x62, 0x65, 0x72, 0x10, 0x03, 0x12, 0x10, 0x0a, 0x0c, 0x53, 0x74, 0x6f, 0x72,
+	0x61, 0x67, 0x65, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x10, 0x04, 0x32, 0xbb, 0x03, 0x0a, 0x07, 0x50,
+	0x61, 0x78, 0x6f, 0x73, 0x4b, 0x56, 0x12, 0x31, 0x0a, 0x07, 0x50, 0x72, 0x65, 0x70, 0x61, 0x72,
+	0x65, 0x12, 0x11, 0x2e, 0x70, 0x61, 0x78, 0x6f, 0x73, 0x6b, 0x76, 0x2e, 0x50, 0x72, 0x6f, 0x70,
+	0x6f, 0x73, 0x65, 0x72, 0x1a, 0x11, 0x2e, 0x70, 0x61, 0x78, 0x6f, 0x73, 0x6b, 0x76, 0x2e, 0x41,
+	0x63, 0x63, 0x65, 0x70, 0x74, 0x6f, 0x72, 0x22, 0x00, 0x12, 0x30, 0x0a, 0x06, 0x41, 0x63, 0x63,
+	0x65, 0x70, 0x74, 0x12, 0x11, 0x2e, 0x70, 0x61, 0x78, 0x6f, 0x73, 0x6b, 0x76, 0x2e, 0x50, 0x72,
+	0x6f, 0x70, 0x6f, 0x73, 0x65, 0x72, 0x1a, 0x11, 0x2e, 0x70, 0x61, 0x78, 0x6f, 0x73, 0x6b, 0x76,
+	0x2e, 0x41, 0x63, 0x63, 0x65, 0x70, 0x74, 0x6f, 0x72, 0x22, 0x00, 0x12, 0x30, 0x0a, 0x06, 0x43,
+	0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x12, 0x11, 0x2e, 0x70, 0x61, 0x78, 0x6f, 0x73, 0x6b, 0x76, 0x2e,
+	0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x65, 0x72, 0x1a, 0x11, 0x2e, 0x70, 0x61, 0x78, 0x6f, 0x73,
+	0x6b, 0x76, 0x2e, 0x41, 0x63, 0x63, 0x65, 0x70, 0x74, 0x6f, 0x72, 0x22, 0x00, 0x12, 0x2d, 0x0a,
+	0x04, 0x4b, 0x65, 0x79, 0x73, 0x12, 0x11, 0x2e, 0x70, 0x61, 0x78, 0x6f, 0x73, 0x6b, 0x76, 0x2e,
+	0x4b, 0x65, 0x79, 0x52, 0x61, 0x6e, 0x67, 0x65, 0x1a, 0x10, 0x2e, 0x70, 0x61, 0x78, 0x6f, 0x73,
+	0x6b, 0x76, 0x2e, 0x4b, 0x65, 0x79, 0x4c, 0x69, 0x73, 0x74, 0x22, 0x00, 0x12, 0x33, 0x0a, 0x05,
+	0x57, 0x61, 0x74, 0x63, 0x68, 0x12, 0x15, 0x2e, 0x70, 0x61, 0x78, 0x6f, 0x73, 0x6b, 0x76, 0x2e,
+	0x57, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0f, 0x2e, 0x70,
+	0x61, 0x78, 0x6f, 0x73, 0x6b, 0x76, 0x2e, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x22, 0x00, 0x30,
+	0x01, 0x12, 0x35, 0x0a, 0x05, 0x46, 0x65, 0x6e, 0x63, 0x65, 0x12, 0x15, 0x2e, 0x70, 0x61, 0x78,
+	0x6f, 0x73, 0x6b, 0x76, 0x2e, 0x46, 0x65, 0x6e, 0x63, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x13, 0x2e, 0x70, 0x61, 0x78, 0x6f, 0x73, 0x6b, 0x76, 0x2e, 0x46, 0x65, 0x6e, 0x63,
+	0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x38, 0x0a, 0x07, 0x49, 0x6e, 0x73, 0x70,
+	0x65, 0x63, 0x74, 0x12, 0x18, 0x2e, 0x70, 0x61, 0x78, 0x6f, 0x73, 0x6b, 0x76, 0x2e, 0x50, 0x61,
+	0x78, 0x6f, 0x73, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x49, 0x64, 0x1a, 0x11, 0x2e,
+	0x70, 0x61, 0x78, 0x6f, 0x73, 0x6b, 0x76, 0x2e, 0x41, 0x63, 0x63, 0x65, 0x70, 0x74, 0x6f, 0x72,
+	0x22, 0x00, 0x12, 0x44, 0x0a, 0x0c, 0x4c, 0x61, 0x74, 0x65, 0x73, 0x74, 0x43, 0x68, 0x6f, 0x73,
+	0x65, 0x6e, 0x12, 0x18, 0x2e, 0x70, 0x61, 0x78, 0x6f, 0x73, 0x6b, 0x76, 0x2e, 0x50, 0x61, 0x78,
+	0x6f, 0x73, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x49, 0x64, 0x1a, 0x18, 0x2e, 0x70,
+	0x61, 0x78, 0x6f, 0x73, 0x6b, 0x76, 0x2e, 0x50, 0x61, 0x78, 0x6f, 0x73, 0x49, 0x6e, 0x73, 0x74,
+	0x61, 0x6e, 0x63, 0x65, 0x49, 0x64, 0x22, 0x00, 0x32, 0xc1, 0x01, 0x0a, 0x09, 0x4b, 0x56, 0x53,
+	0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x29, 0x0a, 0x03, 0x50, 0x75, 0x74, 0x12, 0x0f, 0x2e,
+	0x70, 0x61, 0x78, 0x6f, 0x73, 0x6b, 0x76, 0x2e, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x1a, 0x0f,
+	0x2e, 0x70, 0x61, 0x78, 0x6f, 0x73, 0x6b, 0x76, 0x2e, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x22,
+	0x00, 0x12, 0x29, 0x0a, 0x03, 0x47, 0x65, 0x74, 0x12, 0x0f, 0x2e, 0x70, 0x61, 0x78, 0x6f, 0x73,
+	0x6b, 0x76, 0x2e, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x1a, 0x0f, 0x2e, 0x70, 0x61, 0x78, 0x6f,
+	0x73, 0x6b, 0x76, 0x2e, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x22, 0x00, 0x12, 0x2c, 0x0a, 0x06,
+	0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x12, 0x0f, 0x2e, 0x70, 0x61, 0x78, 0x6f, 0x73, 0x6b, 0x76,
+	0x2e, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x1a, 0x0f, 0x2e, 0x70, 0x61, 0x78, 0x6f, 0x73, 0x6b,
+	0x76, 0x2e, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x22, 0x00, 0x12, 0x30, 0x0a, 0x04, 0x53, 0x63,
+	0x61, 0x6e, 0x12, 0x11, 0x2e, 0x70, 0x61, 0x78, 0x6f, 0x73, 0x6b, 0x76, 0x2e, 0x4b, 0x65, 0x79,
+	0x52, 0x61, 0x6e, 0x67, 0x65, 0x1a, 0x13, 0x2e, 0x70, 0x61, 0x78, 0x6f, 0x73, 0x6b, 0x76, 0x2e,
+	0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x4c, 0x69, 0x73, 0x74, 0x22, 0x00, 0x32, 0xb2, 0x01, 0x0a,
+	0x05, 0x41, 0x64, 0x6d, 0x69, 0x6e, 0x12, 0x31, 0x0a, 0x08, 0x4c, 0x69, 0x73, 0x74, 0x4b, 0x65,
+	0x79, 0x73, 0x12, 0x11, 0x2e, 0x70, 0x61, 0x78, 0x6f, 0x73, 0x6b, 0x76, 0x2e, 0x4b, 0x65, 0x79,
+	0x52, 0x61, 0x6e, 0x67, 0x65, 0x1a, 0x10, 0x2e, 0x70, 0x61, 0x78, 0x6f, 0x73, 0x6b, 0x76, 0x2e,
+	0x4b, 0x65, 0x79, 0x4c, 0x69, 0x73, 0x74, 0x22, 0x00, 0x12, 0x3c, 0x0a, 0x0b, 0x47, 0x65, 0x74,
+	0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x12, 0x18, 0x2e, 0x70, 0x61, 0x78, 0x6f, 0x73,
+	0x6b, 0x76, 0x2e, 0x50, 0x61, 0x78, 0x6f, 0x73, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65,
+	0x49, 0x64, 0x1a, 0x11, 0x2e, 0x70, 0x61, 0x78, 0x6f, 0x73, 0x6b, 0x76, 0x2e, 0x41, 0x63, 0x63,
+	0x65, 0x70, 0x74, 0x6f, 0x72, 0x22, 0x00, 0x12, 0x38, 0x0a, 0x05, 0x53, 0x74, 0x61, 0x74, 0x73,
+	0x12, 0x15, 0x2e, 0x70, 0x61, 0x78, 0x6f, 0x73, 0x6b, 0x76, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x73,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x70, 0x61, 0x78, 0x6f, 0x73, 0x6b,
+	0x76, 0x2e, 0x41, 0x63, 0x63, 0x65, 0x70, 0x74, 0x6f, 0x72, 0x53, 0x74, 0x61, 0x74, 0x73, 0x22,
+	0x00, 0x42, 0x1d, 0x5a, 0x1b, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f,
+	0x6f, 0x70, 0x65, 0x6e, 0x61, 0x63, 0x69, 0x64, 0x2f, 0x70, 0x61, 0x78, 0x6f, 0x73, 0x6b, 0x76,
+	0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_paxoskv_proto_rawDescOnce sync.Once
+	file_paxoskv_proto_rawDescData = file_paxoskv_proto_rawDesc
+)
+
+func file_paxoskv_proto_rawDescGZIP() []byte {
+	file_paxoskv_proto_rawDescOnce.Do(func() {
+		file_paxoskv_proto_rawDescData = protoimpl.X.CompressGZIP(file_paxoskv_proto_rawDescData)
+	})
+	return file_paxoskv_proto_rawDescData
+}
+
+var file_paxoskv_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_paxoskv_proto_msgTypes = make([]protoimpl.MessageInfo, 18)
+var file_paxoskv_proto_goTypes = []interface{}{
+	(AcceptorStatus)(0),     // 0: paxoskv.AcceptorStatus
+	(*BallotNum)(nil),       // 1: paxoskv.BallotNum
+	(*Value)(nil),           // 2: paxoskv.Value
+	(*PaxosInstanceId)(nil), // 3: paxoskv.PaxosInstanceId
+	(*Acceptor)(nil),        // 4: paxoskv.Acceptor
+	(*Proposer)(nil),        // 5: paxoskv.Proposer
+	(*KeyRange)(nil),        // 6: paxoskv.KeyRange
+	(*KeyList)(nil),         // 7: paxoskv.KeyList
+	(*Record)(nil),          // 8: paxoskv.Record
+	(*WatchRequest)(nil),    // 9: paxoskv.WatchRequest
+	(*FenceRequest)(nil),    // 10: paxoskv.FenceRequest
+	(*Instance)(nil),        // 11: paxoskv.Instance
+	(*FenceReply)(nil),      // 12: paxoskv.FenceReply
+	(*LogEntry)(nil),        // 13: paxoskv.LogEntry
+	(*RecordList)(nil),      // 14: paxoskv.RecordList
+	(*StatsRequest)(nil),    // 15: paxoskv.StatsRequest
+	(*AcceptorStats)(nil),   // 16: paxoskv.AcceptorStats
+	nil,                     // 17: paxoskv.FenceRequest.CompactedEntry
+	nil,                     // 18: paxoskv.FenceReply.CompactedEntry
+}
+var file_paxoskv_proto_depIdxs = []int32{
+	1,  // 0: paxoskv.Acceptor.LastBal:type_name -> paxoskv.BallotNum
+	2,  // 1: paxoskv.Acceptor.Val:type_name -> paxoskv.Value
+	1,  // 2: paxoskv.Acceptor.VBal:type_name -> paxoskv.BallotNum
+	0,  // 3: paxoskv.Acceptor.Status:type_name -> paxoskv.AcceptorStatus
+	3,  // 4: paxoskv.Proposer.Id:type_name -> paxoskv.PaxosInstanceId
+	1,  // 5: paxoskv.Proposer.Bal:type_name -> paxoskv.BallotNum
+	2,  // 6: paxoskv.Proposer.Val:type_name -> paxoskv.Value
+	2,  // 7: paxoskv.Record.Val:type_name -> paxoskv.Value
+	1,  // 8: paxoskv.Record.Bal:type_name -> paxoskv.BallotNum
+	6,  // 9: paxoskv.FenceRequest.Range:type_name -> paxoskv.KeyRange
+	17, // 10: paxoskv.FenceRequest.Compacted:type_name -> paxoskv.FenceRequest.CompactedEntry
+	3,  // 11: paxoskv.Instance.Id:type_name -> paxoskv.PaxosInstanceId
+	4,  // 12: paxoskv.Instance.Acceptor:type_name -> paxoskv.Acceptor
+	11, // 13: paxoskv.FenceReply.Instances:type_name -> paxoskv.Instance
+	18, // 14: paxoskv.FenceReply.Compacted:type_name -> paxoskv.FenceReply.CompactedEntry
+	11, // 15: paxoskv.LogEntry.Instance:type_name -> paxoskv.Instance
+	3,  // 16: paxoskv.LogEntry.Drop:type_name -> paxoskv.PaxosInstanceId
+	10, // 17: paxoskv.LogEntry.Fence:type_name -> paxoskv.FenceRequest
+	3,  // 18: paxoskv.LogEntry.Compact:type_name -> paxoskv.PaxosInstanceId
+	8,  // 19: paxoskv.RecordList.Records:type_name -> paxoskv.Record
+	5,  // 20: paxoskv.PaxosKV.Prepare:input_type -> paxoskv.Proposer
+	5,  // 21: paxoskv.PaxosKV.Accept:input_type -> paxoskv.Proposer
+	5,  // 22: paxoskv.PaxosKV.Commit:input_type -> paxoskv.Proposer
+	6,  // 23: paxoskv.PaxosKV.Keys:input_type -> paxoskv.KeyRange
+	9,  // 24: paxoskv.PaxosKV.Watch:input_type -> paxoskv.WatchRequest
+	10, // 25: paxoskv.PaxosKV.Fence:input_type -> paxoskv.FenceRequest
+	3,  // 26: paxoskv.PaxosKV.Inspect:input_type -> paxoskv.PaxosInstanceId
+	3,  // 27: paxoskv.PaxosKV.LatestChosen:input_type -> paxoskv.PaxosInstanceId
+	8,  // 28: paxoskv.KVService.Put:input_type -> paxoskv.Record
+	8,  // 29: paxoskv.KVService.Get:input_type -> paxoskv.Record
+	8,  // 30: paxoskv.KVService.Delete:input_type -> paxoskv.Record
+	6,  // 31: paxoskv.KVService.Scan:input_type -> paxoskv.KeyRange
+	6,  // 32: paxoskv.Admin.ListKeys:input_type -> paxoskv.KeyRange
+	3,  // 33: paxoskv.Admin.GetInstance:input_type -> paxoskv.PaxosInstanceId
+	15, // 34: paxoskv.Admin.Stats:input_type -> paxoskv.StatsRequest
+	4,  // 35: paxoskv.PaxosKV.Prepare:output_type -> paxoskv.Acceptor
+	4,  // 36: paxoskv.PaxosKV.Accept:output_type -> paxoskv.Acceptor
+	4,  // 37: paxoskv.PaxosKV.Commit:output_type -> paxoskv.Acceptor
+	7,  // 38: paxoskv.PaxosKV.Keys:output_type -> paxoskv.KeyList
+	8,  // 39: paxoskv.PaxosKV.Watch:output_type -> paxoskv.Record
+	12, // 40: paxoskv.PaxosKV.Fence:output_type -> paxoskv.FenceReply
+	4,  // 41: paxoskv.PaxosKV.Inspect:output_type -> paxoskv.Acceptor
+	3,  // 42: paxoskv.PaxosKV.LatestChosen:output_type -> paxoskv.PaxosInstanceId
+	8,  // 43: paxoskv.KVService.Put:output_type -> paxoskv.Record
+	8,  // 44: paxoskv.KVService.Get:output_type -> paxoskv.Record
+	8,  // 45: paxoskv.KVService.Delete:output_type -> paxoskv.Record
+	14, // 46: paxoskv.KVService.Scan:output_type -> paxoskv.RecordList
+	7,  // 47: paxoskv.Admin.ListKeys:output_type -> paxoskv.KeyList
+	4,  // 48: paxoskv.Admin.GetInstance:output_type -> paxoskv.Acceptor
+	16, // 49: paxoskv.Admin.Stats:output_type -> paxoskv.AcceptorStats
+	35, // [35:50] is the sub-list for method output_type
+	20, // [20:35] is the sub-list for method input_type
+	20, // [20:20] is the sub-list for extension type_name
+	20, // [20:20] is the sub-list for extension extendee
+	0,  // [0:20] is the sub-list for field type_name
+}
+
+func init() { file_paxoskv_proto_init() }
+func file_paxoskv_proto_init() {
+	if File_paxoskv_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_paxoskv_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BallotNum); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_paxoskv_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Value); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_paxoskv_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PaxosInstanceId); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_paxoskv_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Acceptor); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_paxoskv_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Proposer); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_paxoskv_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*KeyRange); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_paxoskv_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*KeyList); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_paxoskv_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Record); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_paxoskv_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*WatchRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_paxoskv_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FenceRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_paxoskv_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Instance); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_paxoskv_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FenceReply); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_paxoskv_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*LogEntry); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_paxoskv_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RecordList); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_paxoskv_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StatsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_paxoskv_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AcceptorStats); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_paxoskv_proto_rawDesc,
+			NumEnums:      1,
+			NumMessages:   18,
+			NumExtensions: 0,
+			NumServices:   3,
+		},
+		GoTypes:           file_paxoskv_proto_goTypes,
+		DependencyIndexes: file_paxoskv_proto_depIdxs,
+		EnumInfos:         file_paxoskv_proto_enumTypes,
+		MessageInfos:      file_paxoskv_proto_msgTypes,
+	}.Build()
+	File_paxoskv_proto = out.File
+	file_paxoskv_proto_rawDesc = nil
+	file_paxoskv_proto_goTypes = nil
+	file_paxoskv_proto_depIdxs = nil
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConnInterface
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion6
+
+// PaxosKVClient is the client API for PaxosKV service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type PaxosKVClient interface {
+	Prepare(ctx context.Context, in *Proposer, opts ...grpc.CallOption) (*Acceptor, error)
+	Accept(ctx context.Context, in *Proposer, opts ...grpc.CallOption) (*Acceptor, error)
+	Commit(ctx context.Context, in *Proposer, opts ...grpc.CallOption) (*Acceptor, error)
+	Keys(ctx context.Context, in *KeyRange, opts ...grpc.CallOption) (*KeyList, error)
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (PaxosKV_WatchClient, error)
+	Fence(ctx context.Context, in *FenceRequest, opts ...grpc.CallOption) (*FenceReply, error)
+	Inspect(ctx context.Context, in *PaxosInstanceId, opts ...grpc.CallOption) (*Acceptor, error)
+	LatestChosen(ctx context.Context, in *PaxosInstanceId, opts ...grpc.CallOption) (*PaxosInstanceId, error)
+}
+
+type paxosKVClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPaxosKVClient(cc grpc.ClientConnInterface) PaxosKVClient {
+	return &paxosKVClient{cc}
+}
+
+func (c *paxosKVClient) Prepare(ctx context.Context, in *Proposer, opts ...grpc.CallOption) (*Acceptor, error) {
+	out := new(Acceptor)
+	err := c.cc.Invoke(ctx, "/paxoskv.PaxosKV/Prepare", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paxosKVClient) Accept(ctx context.Context, in *Proposer, opts ...grpc.CallOption) (*Acceptor, error) {
+	out := new(Acceptor)
+	err := c.cc.Invoke(ctx, "/paxoskv.PaxosKV/Accept", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paxosKVClient) Commit(ctx context.Context, in *Proposer, opts ...grpc.CallOption) (*Acceptor, error) {
+	out := new(Acceptor)
+	err := c.cc.Invoke(ctx, "/paxoskv.PaxosKV/Commit", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paxosKVClient) Keys(ctx context.Context, in *KeyRange, opts ...grpc.CallOption) (*KeyList, error) {
+	out := new(KeyList)
+	err := c.cc.Invoke(ctx, "/paxoskv.PaxosKV/Keys", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paxosKVClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (PaxosKV_WatchClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_PaxosKV_serviceDesc.Streams[0], "/paxoskv.PaxosKV/Watch", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &paxosKVWatchClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type PaxosKV_WatchClient interface {
+	Recv() (*Record, error)
+	grpc.ClientStream
+}
+
+type paxosKVWatchClient struct {
+	grpc.ClientStream
+}
+
+func (x *paxosKVWatchClient) Recv() (*Record, error) {
+	m := new(Record)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *paxosKVClient) Fence(ctx context.Context, in *FenceRequest, opts ...grpc.CallOption) (*FenceReply, error) {
+	out := new(FenceReply)
+	err := c.cc.Invoke(ctx, "/paxoskv.PaxosKV/Fence", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paxosKVClient) Inspect(ctx context.Context, in *PaxosInstanceId, opts ...grpc.CallOption) (*Acceptor, error) {
+	out := new(Acceptor)
+	err := c.cc.Invoke(ctx, "/paxoskv.PaxosKV/Inspect", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paxosKVClient) LatestChosen(ctx context.Context, in *PaxosInstanceId, opts ...grpc.CallOption) (*PaxosInstanceId, error) {
+	out := new(PaxosInstanceId)
+	err := c.cc.Invoke(ctx, "/paxoskv.PaxosKV/LatestChosen", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PaxosKVServer is the server API for PaxosKV service.
+type PaxosKVServer interface {
+	Prepare(context.Context, *Proposer) (*Acceptor, error)
+	Accept(context.Context, *Proposer) (*Acceptor, error)
+	Commit(context.Context, *Proposer) (*Acceptor, error)
+	Keys(context.Context, *KeyRange) (*KeyList, error)
+	Watch(*WatchRequest, PaxosKV_WatchServer) error
+	Fence(context.Context, *FenceRequest) (*FenceReply, error)
+	Inspect(context.Context, *PaxosInstanceId) (*Acceptor, error)
+	LatestChosen(context.Context, *PaxosInstanceId) (*PaxosInstanceId, error)
+}
+
+// UnimplementedPaxosKVServer can be embedded to have forward compatible implementations.
+type UnimplementedPaxosKVServer struct {
+}
+
+func (*UnimplementedPaxosKVServer) Prepare(context.Context, *Proposer) (*Acceptor, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Prepare not implemented")
+}
+func (*UnimplementedPaxosKVServer) Accept(context.Context, *Proposer) (*Acceptor, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Accept not implemented")
+}
+func (*UnimplementedPaxosKVServer) Commit(context.Context, *Proposer) (*Acceptor, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Commit not implemented")
+}
+func (*UnimplementedPaxosKVServer) Keys(context.Context, *KeyRange) (*KeyList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Keys not implemented")
+}
+func (*UnimplementedPaxosKVServer) Watch(*WatchRequest, PaxosKV_WatchServer) error {
+	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
+}
+func (*UnimplementedPaxosKVServer) Fence(context.Context, *FenceRequest) (*FenceReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Fence not implemented")
+}
+func (*UnimplementedPaxosKVServer) Inspect(context.Context, *PaxosInstanceId) (*Acceptor, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Inspect not implemented")
+}
+func (*UnimplementedPaxosKVServer) LatestChosen(context.Context, *PaxosInstanceId) (*PaxosInstanceId, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LatestChosen not implemented")
+}
+
+func RegisterPaxosKVServer(s *grpc.Server, srv PaxosKVServer) {
+	s.RegisterService(&_PaxosKV_serviceDesc, srv)
+}
+
+func _PaxosKV_Prepare_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Proposer)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaxosKVServer).Prepare(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/paxoskv.PaxosKV/Prepare",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaxosKVServer).Prepare(ctx, req.(*Proposer))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaxosKV_Accept_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Proposer)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaxosKVServer).Accept(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/paxoskv.PaxosKV/Accept",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaxosKVServer).Accept(ctx, req.(*Proposer))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaxosKV_Commit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Proposer)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaxosKVServer).Commit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/paxoskv.PaxosKV/Commit",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaxosKVServer).Commit(ctx, req.(*Proposer))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaxosKV_Keys_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KeyRange)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaxosKVServer).Keys(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/paxoskv.PaxosKV/Prepare",
+		FullMethod: "/paxoskv.PaxosKV/Keys",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(PaxosKVServer).Prepare(ctx, req.(*Proposer))
+		return srv.(PaxosKVServer).Keys(ctx, req.(*KeyRange))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _PaxosKV_Accept_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(Proposer)
+func _PaxosKV_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PaxosKVServer).Watch(m, &paxosKVWatchServer{stream})
+}
+
+type PaxosKV_WatchServer interface {
+	Send(*Record) error
+	grpc.ServerStream
+}
+
+type paxosKVWatchServer struct {
+	grpc.ServerStream
+}
+
+func (x *paxosKVWatchServer) Send(m *Record) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _PaxosKV_Fence_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FenceRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(PaxosKVServer).Accept(ctx, in)
+		return srv.(PaxosKVServer).Fence(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/paxoskv.PaxosKV/Accept",
+		FullMethod: "/paxoskv.PaxosKV/Fence",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(PaxosKVServer).Accept(ctx, req.(*Proposer))
+		return srv.(PaxosKVServer).Fence(ctx, req.(*FenceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaxosKV_Inspect_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PaxosInstanceId)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaxosKVServer).Inspect(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/paxoskv.PaxosKV/Inspect",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaxosKVServer).Inspect(ctx, req.(*PaxosInstanceId))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaxosKV_LatestChosen_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PaxosInstanceId)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaxosKVServer).LatestChosen(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/paxoskv.PaxosKV/LatestChosen",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaxosKVServer).LatestChosen(ctx, req.(*PaxosInstanceId))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -605,6 +1968,356 @@ var _PaxosKV_serviceDesc = grpc.ServiceDesc{
 			MethodName: "Accept",
 			Handler:    _PaxosKV_Accept_Handler,
 		},
+		{
+			MethodName: "Commit",
+			Handler:    _PaxosKV_Commit_Handler,
+		},
+		{
+			MethodName: "Keys",
+			Handler:    _PaxosKV_Keys_Handler,
+		},
+		{
+			MethodName: "Fence",
+			Handler:    _PaxosKV_Fence_Handler,
+		},
+		{
+			MethodName: "Inspect",
+			Handler:    _PaxosKV_Inspect_Handler,
+		},
+		{
+			MethodName: "LatestChosen",
+			Handler:    _PaxosKV_LatestChosen_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Watch",
+			Handler:       _PaxosKV_Watch_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "paxoskv.proto",
+}
+
+// KVServiceClient is the client API for KVService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type KVServiceClient interface {
+	Put(ctx context.Context, in *Record, opts ...grpc.CallOption) (*Record, error)
+	Get(ctx context.Context, in *Record, opts ...grpc.CallOption) (*Record, error)
+	Delete(ctx context.Context, in *Record, opts ...grpc.CallOption) (*Record, error)
+	Scan(ctx context.Context, in *KeyRange, opts ...grpc.CallOption) (*RecordList, error)
+}
+
+type kVServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewKVServiceClient(cc grpc.ClientConnInterface) KVServiceClient {
+	return &kVServiceClient{cc}
+}
+
+func (c *kVServiceClient) Put(ctx context.Context, in *Record, opts ...grpc.CallOption) (*Record, error) {
+	out := new(Record)
+	err := c.cc.Invoke(ctx, "/paxoskv.KVService/Put", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kVServiceClient) Get(ctx context.Context, in *Record, opts ...grpc.CallOption) (*Record, error) {
+	out := new(Record)
+	err := c.cc.Invoke(ctx, "/paxoskv.KVService/Get", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kVServiceClient) Delete(ctx context.Context, in *Record, opts ...grpc.CallOption) (*Record, error) {
+	out := new(Record)
+	err := c.cc.Invoke(ctx, "/paxoskv.KVService/Delete", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kVServiceClient) Scan(ctx context.Context, in *KeyRange, opts ...grpc.CallOption) (*RecordList, error) {
+	out := new(RecordList)
+	err := c.cc.Invoke(ctx, "/paxoskv.KVService/Scan", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// KVServiceServer is the server API for KVService service.
+type KVServiceServer interface {
+	Put(context.Context, *Record) (*Record, error)
+	Get(context.Context, *Record) (*Record, error)
+	Delete(context.Context, *Record) (*Record, error)
+	Scan(context.Context, *KeyRange) (*RecordList, error)
+}
+
+// UnimplementedKVServiceServer can be embedded to have forward compatible implementations.
+type UnimplementedKVServiceServer struct {
+}
+
+func (*UnimplementedKVServiceServer) Put(context.Context, *Record) (*Record, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Put not implemented")
+}
+func (*UnimplementedKVServiceServer) Get(context.Context, *Record) (*Record, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Get not implemented")
+}
+func (*UnimplementedKVServiceServer) Delete(context.Context, *Record) (*Record, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
+}
+func (*UnimplementedKVServiceServer) Scan(context.Context, *KeyRange) (*RecordList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Scan not implemented")
+}
+
+func RegisterKVServiceServer(s *grpc.Server, srv KVServiceServer) {
+	s.RegisterService(&_KVService_serviceDesc, srv)
+}
+
+func _KVService_Put_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Record)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KVServiceServer).Put(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/paxoskv.KVService/Put",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KVServiceServer).Put(ctx, req.(*Record))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KVService_Get_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Record)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KVServiceServer).Get(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/paxoskv.KVService/Get",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KVServiceServer).Get(ctx, req.(*Record))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KVService_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Record)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KVServiceServer).Delete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/paxoskv.KVService/Delete",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KVServiceServer).Delete(ctx, req.(*Record))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KVService_Scan_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KeyRange)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KVServiceServer).Scan(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/paxoskv.KVService/Scan",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KVServiceServer).Scan(ctx, req.(*KeyRange))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _KVService_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "paxoskv.KVService",
+	HandlerType: (*KVServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Put",
+			Handler:    _KVService_Put_Handler,
+		},
+		{
+			MethodName: "Get",
+			Handler:    _KVService_Get_Handler,
+		},
+		{
+			MethodName: "Delete",
+			Handler:    _KVService_Delete_Handler,
+		},
+		{
+			MethodName: "Scan",
+			Handler:    _KVService_Scan_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "paxoskv.proto",
+}
+
+// AdminClient is the client API for Admin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type AdminClient interface {
+	ListKeys(ctx context.Context, in *KeyRange, opts ...grpc.CallOption) (*KeyList, error)
+	GetInstance(ctx context.Context, in *PaxosInstanceId, opts ...grpc.CallOption) (*Acceptor, error)
+	Stats(ctx context.Context, in *StatsRequest, opts ...grpc.CallOption) (*AcceptorStats, error)
+}
+
+type adminClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAdminClient(cc grpc.ClientConnInterface) AdminClient {
+	return &adminClient{cc}
+}
+
+func (c *adminClient) ListKeys(ctx context.Context, in *KeyRange, opts ...grpc.CallOption) (*KeyList, error) {
+	out := new(KeyList)
+	err := c.cc.Invoke(ctx, "/paxoskv.Admin/ListKeys", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) GetInstance(ctx context.Context, in *PaxosInstanceId, opts ...grpc.CallOption) (*Acceptor, error) {
+	out := new(Acceptor)
+	err := c.cc.Invoke(ctx, "/paxoskv.Admin/GetInstance", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) Stats(ctx context.Context, in *StatsRequest, opts ...grpc.CallOption) (*AcceptorStats, error) {
+	out := new(AcceptorStats)
+	err := c.cc.Invoke(ctx, "/paxoskv.Admin/Stats", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AdminServer is the server API for Admin service.
+type AdminServer interface {
+	ListKeys(context.Context, *KeyRange) (*KeyList, error)
+	GetInstance(context.Context, *PaxosInstanceId) (*Acceptor, error)
+	Stats(context.Context, *StatsRequest) (*AcceptorStats, error)
+}
+
+// UnimplementedAdminServer can be embedded to have forward compatible implementations.
+type UnimplementedAdminServer struct {
+}
+
+func (*UnimplementedAdminServer) ListKeys(context.Context, *KeyRange) (*KeyList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListKeys not implemented")
+}
+func (*UnimplementedAdminServer) GetInstance(context.Context, *PaxosInstanceId) (*Acceptor, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetInstance not implemented")
+}
+func (*UnimplementedAdminServer) Stats(context.Context, *StatsRequest) (*AcceptorStats, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Stats not implemented")
+}
+
+func RegisterAdminServer(s *grpc.Server, srv AdminServer) {
+	s.RegisterService(&_Admin_serviceDesc, srv)
+}
+
+func _Admin_ListKeys_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KeyRange)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).ListKeys(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/paxoskv.Admin/ListKeys",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).ListKeys(ctx, req.(*KeyRange))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_GetInstance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PaxosInstanceId)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).GetInstance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/paxoskv.Admin/GetInstance",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).GetInstance(ctx, req.(*PaxosInstanceId))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_Stats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).Stats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/paxoskv.Admin/Stats",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).Stats(ctx, req.(*StatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Admin_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "paxoskv.Admin",
+	HandlerType: (*AdminServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListKeys",
+			Handler:    _Admin_ListKeys_Handler,
+		},
+		{
+			MethodName: "GetInstance",
+			Handler:    _Admin_GetInstance_Handler,
+		},
+		{
+			MethodName: "Stats",
+			Handler:    _Admin_Stats_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "paxoskv.proto",
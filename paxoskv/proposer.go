@@ -0,0 +1,196 @@
+package paxoskv
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+const (
+	// proposerIdKey is the counter of allocated ProposerIds.
+	proposerIdKey = internalKeyPrefix + "proposer-id"
+
+	// AllocatedProposerIdBase is the smallest ProposerId AllocProposerId
+	// returns. ProposerIds below it are left for proposers configured by hand.
+	AllocatedProposerIdBase = int64(1) << 32
+
+	// ballotReserve is the number of ballots a client reserves in StateFile
+	// at a time.
+	ballotReserve = int64(1000)
+
+	// allocTimeout is how long AllocProposerId waits for a quorum.
+	allocTimeout = 10 * time.Second
+)
+
+// ProposerState is the durable state of a proposer, stored in
+// KVClient.StateFile.
+type ProposerState struct {
+	ProposerId int64
+
+	// MaxBal is the highest ballot N the proposer may have used.
+	MaxBal int64
+}
+
+// AllocProposerId returns a ProposerId no other call to it in the cluster
+// returns, by incrementing a counter with paxos.
+//
+// Running paxos needs a ProposerId too: it uses one by RandomProposerId.
+//
+// If no quorum is reached in allocTimeout, it returns the error of the deadline.
+func (c *KVClient) AllocProposerId() (int64, error) {
+
+	tmpId, err := RandomProposerId()
+	if err != nil {
+		return 0, err
+	}
+
+	alloc := &KVClient{
+		AcceptorIds:     c.AcceptorIds,
+		Shards:          c.shardMap(),
+		MetaAcceptorIds: c.MetaAcceptorIds,
+		ProposerId:      tmpId,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), allocTimeout)
+	defer cancel()
+
+	n, err := alloc.increment(ctx, proposerIdKey, 1)
+	if err != nil {
+		return 0, err
+	}
+
+	id := AllocatedProposerIdBase + n
+	c.log(LevelInfo, "KVClient: allocated ProposerId", F("proposer", id))
+	return id, nil
+}
+
+// LoadState loads ProposerId and the highest ballot N used from StateFile.
+//
+// If StateFile does not exist, ProposerId is allocated with AllocProposerId,
+// unless it is already set, and is stored in a new StateFile.
+//
+// Ballots are reserved in StateFile in batches before being used, thus after a
+// restart, a client starts from a ballot N higher than any it has used.
+func (c *KVClient) LoadState() error {
+
+	if c.StateFile == "" {
+		return errors.New("no StateFile")
+	}
+
+	data, err := os.ReadFile(c.StateFile)
+	if errors.Is(err, os.ErrNotExist) {
+		if c.ProposerId == 0 {
+			id, err := c.AllocProposerId()
+			if err != nil {
+				return err
+			}
+			c.ProposerId = id
+		}
+
+		c.balMu.Lock()
+		defer c.balMu.Unlock()
+		return c.saveState(c.bal)
+	}
+	if err != nil {
+		return err
+	}
+
+	st := &ProposerState{}
+	if err := json.Unmarshal(data, st); err != nil {
+		return fmt.Errorf("invalid state file: %s: %w", c.StateFile, err)
+	}
+
+	c.balMu.Lock()
+	defer c.balMu.Unlock()
+
+	c.ProposerId = st.ProposerId
+	c.bal = st.MaxBal
+	c.balLimit = st.MaxBal
+
+	c.log(LevelInfo, "KVClient: loaded state", F("proposer", st.ProposerId), F("maxbal", st.MaxBal))
+	return nil
+}
+
+// nextBal returns a ballot N greater than `higher` and than any this client
+// has used. If StateFile is set, the returned ballot N is stored in it before
+// it is returned. A client with balOwner uses the ballots of balOwner.
+func (c *KVClient) nextBal(higher int64) (int64, error) {
+
+	if c.balOwner != nil {
+		return c.balOwner.nextBal(higher)
+	}
+
+	c.balMu.Lock()
+	defer c.balMu.Unlock()
+
+	n := c.bal + 1
+	if n <= higher {
+		n = higher + 1
+	}
+
+	if c.StateFile != "" && n > c.balLimit {
+		if err := c.saveState(n + ballotReserve); err != nil {
+			return 0, err
+		}
+	}
+
+	c.bal = n
+	return n, nil
+}
+
+// saveState writes ProposerId and `maxBal` into StateFile atomically.
+// It must be called with balMu held.
+func (c *KVClient) saveState(maxBal int64) error {
+
+	data, err := json.Marshal(&ProposerState{ProposerId: c.ProposerId, MaxBal: maxBal})
+	if err != nil {
+		return err
+	}
+
+	tmp := c.StateFile + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, c.StateFile); err != nil {
+		return err
+	}
+
+	// make the rename durable
+	if dir, err := os.Open(filepath.Dir(c.StateFile)); err == nil {
+		dir.Sync()
+		dir.Close()
+	}
+
+	c.balLimit = maxBal
+	return nil
+}
+
+// RandomProposerId returns a random negative ProposerId, which no allocated or
+// configured ProposerId is. It is for a short-lived client that only reads,
+// and thus does not need to allocate one.
+func RandomProposerId() (int64, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return 0, err
+	}
+	return -int64(binary.BigEndian.Uint64(b)>>1) - 1, nil
+}
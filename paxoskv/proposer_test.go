@@ -0,0 +1,105 @@
+package paxoskv
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKVClient_AllocProposerId(t *testing.T) {
+
+	ta := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+
+	servers, err := ServeAcceptors(acceptorIds)
+	ta.Nil(err)
+	defer func() {
+		for _, s := range servers {
+			s.Stop()
+		}
+	}()
+
+	n := 5
+	ids := make([]int64, n)
+	errs := make([]error, n)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			c := &KVClient{AcceptorIds: acceptorIds}
+			ids[i], errs[i] = c.AllocProposerId()
+		}(i)
+	}
+	wg.Wait()
+
+	seen := map[int64]bool{}
+	for i := 0; i < n; i++ {
+		ta.Nil(errs[i])
+		ta.True(ids[i] > AllocatedProposerIdBase)
+		ta.False(seen[ids[i]], "duplicate id: %d", ids[i])
+		seen[ids[i]] = true
+	}
+}
+
+func TestKVClient_LoadState(t *testing.T) {
+
+	ta := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+
+	servers, err := ServeAcceptors(acceptorIds)
+	ta.Nil(err)
+	defer func() {
+		for _, s := range servers {
+			s.Stop()
+		}
+	}()
+
+	path := filepath.Join(t.TempDir(), "proposer.json")
+
+	c := &KVClient{AcceptorIds: acceptorIds, StateFile: path}
+	ta.Nil(c.LoadState())
+	ta.True(c.ProposerId > AllocatedProposerIdBase)
+
+	_, err = c.Set("foo", &Value{Vi64: 1})
+	ta.Nil(err)
+
+	// a ballot N raised by a higher ballot seen is stored too.
+	n, err := c.nextBal(ballotReserve * 3)
+	ta.Nil(err)
+	ta.Equal(ballotReserve*3+1, n)
+
+	// restart
+	restarted := &KVClient{AcceptorIds: acceptorIds, StateFile: path}
+	ta.Nil(restarted.LoadState())
+	ta.Equal(c.ProposerId, restarted.ProposerId)
+
+	n2, err := restarted.nextBal(0)
+	ta.Nil(err)
+	ta.True(n2 > n, "never reuses a ballot")
+
+	v, _, err := restarted.Get("foo")
+	ta.Nil(err)
+	ta.Equal(int64(1), v.Vi64)
+
+	// the meta client takes ballots from StateFile too.
+	restarted.MetaAcceptorIds = acceptorIds
+	n3, err := restarted.metaClient().nextBal(ballotReserve * 5)
+	ta.Nil(err)
+
+	again := &KVClient{AcceptorIds: acceptorIds, StateFile: path}
+	ta.Nil(again.LoadState())
+	n4, err := again.nextBal(0)
+	ta.Nil(err)
+	ta.True(n4 > n3, "never reuses a ballot of the meta client")
+
+	// a configured ProposerId is kept.
+	configured := &KVClient{AcceptorIds: acceptorIds, ProposerId: 7, StateFile: filepath.Join(t.TempDir(), "p.json")}
+	ta.Nil(configured.LoadState())
+	ta.Equal(int64(7), configured.ProposerId)
+}
@@ -0,0 +1,185 @@
+package paxoskv
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/protobuf/proto"
+)
+
+// scanBatch is the number of keys Scan lists from Acceptors at a time.
+const scanBatch = 64
+
+// Scan returns the latest visible version of every key in [start, end), in
+// key order, at most `limit` of them. An empty `end` means no upper bound and a
+// `limit` <= 0 means no limit. Deleted keys are not returned.
+//
+// Scan is not a snapshot of the keys: it reads keys one by one with Get, thus
+// every record it returns is linearizable as if a Get is done at the time it
+// reads the key. But a key written after Scan passed it is not seen, and two
+// returned records may have been read at different times.
+//
+// Scan lists keys from a quorum of Acceptors: a key with a chosen value has at
+// least a quorum of Acceptors storing it, one of which is in any quorum.
+// With Shards, it scans the shards overlapping [start, end) one by one, each on
+// its own acceptor group.
+func (c *KVClient) Scan(start, end string, limit int) ([]*Record, error) {
+	return c.scan(start, end, limit, nil)
+}
+
+// scan is the same as Scan except that it skips, without reading them, the
+// keys `readable` returns false for. A nil `readable` reads every key.
+func (c *KVClient) scan(start, end string, limit int, readable func(key string) bool) ([]*Record, error) {
+
+	records := []*Record{}
+
+	err := c.eachKey(start, end, func(key string) bool {
+		if readable != nil && !readable(key) {
+			return true
+		}
+		v, ver, err := c.Get(key)
+		if err != NotFound {
+			records = append(records, &Record{Key: key, Ver: ver, Val: v})
+		}
+		return limit <= 0 || len(records) < limit
+	})
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// Expire chooses a tombstone for every key in [start, end) whose latest visible
+// version is expired, and returns the number of tombstones it chose. An empty
+// `end` means no upper bound.
+//
+// An expired version stays until the key is read or written again. Expire
+// writes the tombstones to let GC drop such keys. It needs the
+// permission to write every key, thus it is meant to run in the background
+// with the identity of a server, as paxoskv-server does.
+func (c *KVClient) Expire(start, end string) (int, error) {
+
+	ctx := context.Background()
+	n := 0
+	tombstone := &Value{Deleted: true}
+	var verr error
+
+	err := c.eachKey(start, end, func(key string) bool {
+		v, _, _, err := c.view(ctx, key, false)
+		if err != nil {
+			verr = err
+			return false
+		}
+		if v == nil || v.Deleted || !v.expired(time.Now()) {
+			return true
+		}
+
+		// A tombstone is about to be written over the intents of transactions
+		// not yet decided, decide them first.
+		v, _, ver, err := c.view(ctx, key, true)
+		if err != nil {
+			verr = err
+			return false
+		}
+		if v != nil && !v.Deleted && v.expired(time.Now()) {
+			chosen, err := c.runPaxos(ctx, key, ver+1, tombstone)
+			if err != nil {
+				verr = err
+				return false
+			}
+			if proto.Equal(chosen, tombstone) {
+				c.log(LevelDebug, "KVClient: expired, wrote a tombstone", F("key", key), F("ver", ver+1))
+				n++
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return n, err
+	}
+	return n, verr
+}
+
+// eachKey calls `fn` with every user key in [start, end) in order, until it
+// returns false. Keys are listed from a quorum of the acceptor group of every
+// shard overlapping the range.
+func (c *KVClient) eachKey(start, end string, fn func(key string) bool) error {
+
+	m := c.shardMap()
+
+	for _, shard := range m.Overlap(start, end) {
+
+		from, to := shard.clip(start, end)
+		acceptorIds := m.Groups[shard.GroupId]
+
+		for {
+			keys, err := c.listKeys(acceptorIds, from, to, scanBatch)
+			if err != nil {
+				return err
+			}
+			if len(keys) == 0 {
+				break
+			}
+
+			for _, key := range keys {
+				if strings.HasPrefix(key, internalKeyPrefix) {
+					continue
+				}
+				if !fn(key) {
+					return nil
+				}
+			}
+
+			// the smallest key greater than the last one
+			from = keys[len(keys)-1] + "\x00"
+		}
+	}
+
+	return nil
+}
+
+// listKeys returns the first `n` keys in [start, end) from the union of the
+// keys a quorum of Acceptors have. A `n` <= 0 means no limit.
+func (c *KVClient) listKeys(acceptorIds []int64, start, end string, n int64) ([]string, error) {
+
+	quorum := len(acceptorIds)/2 + 1
+	ok := 0
+	union := map[string]bool{}
+
+	for _, aid := range acceptorIds {
+		var reply *KeyList
+		err := callAcceptor(aid, func(ctx context.Context, cli PaxosKVClient) (err error) {
+			reply, err = cli.Keys(ctx, &KeyRange{Start: start, End: end, Limit: n})
+			return err
+		})
+		if err != nil {
+			c.log(LevelWarn, "KVClient: Keys failure", F("acceptor", aid), F("err", err))
+			continue
+		}
+		c.log(LevelDebug, "KVClient: recv Keys reply", F("acceptor", aid), F("keys", len(reply.Keys)))
+
+		ok++
+		for _, k := range reply.Keys {
+			union[k] = true
+		}
+	}
+
+	if ok < quorum {
+		return nil, NotEnoughQuorum
+	}
+
+	keys := make([]string, 0, len(union))
+	for k := range union {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	// Every Acceptor returns its first n keys, thus the first n of the union
+	// are complete.
+	if n > 0 && int64(len(keys)) > n {
+		keys = keys[:n]
+	}
+	return keys, nil
+}
@@ -0,0 +1,90 @@
+package paxoskv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKVClient_Scan(t *testing.T) {
+
+	ta := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+
+	servers, err := ServeAcceptors(acceptorIds)
+	ta.Nil(err)
+	defer func() {
+		for _, s := range servers {
+			s.Stop()
+		}
+	}()
+
+	c := &KVClient{AcceptorIds: acceptorIds, ProposerId: 2}
+
+	for i, k := range []string{"d", "b", "a", "c", "e"} {
+		_, err := c.Set(k, &Value{Vi64: int64(i)})
+		ta.Nil(err)
+	}
+	_, err = c.Set("b", &Value{Vi64: 10})
+	ta.Nil(err)
+	_, err = c.Delete("c")
+	ta.Nil(err)
+
+	// a transaction record is not returned
+	err = c.Txn(&TxnOp{Key: "f", Val: &Value{Vi64: 5}})
+	ta.Nil(err)
+
+	keysOf := func(records []*Record) []string {
+		keys := []string{}
+		for _, r := range records {
+			keys = append(keys, r.Key)
+		}
+		return keys
+	}
+
+	records, err := c.Scan("", "", 0)
+	ta.Nil(err)
+	ta.Equal([]string{"a", "b", "d", "e", "f"}, keysOf(records))
+	ta.Equal(int64(1), records[1].Ver)
+	ta.Equal(int64(10), records[1].Val.Vi64)
+
+	records, err = c.Scan("b", "e", 0)
+	ta.Nil(err)
+	ta.Equal([]string{"b", "d"}, keysOf(records))
+
+	records, err = c.Scan("b", "", 2)
+	ta.Nil(err)
+	ta.Equal([]string{"b", "d"}, keysOf(records))
+
+	records, err = c.Scan("x", "", 0)
+	ta.Nil(err)
+	ta.Equal([]string{}, keysOf(records))
+}
+
+func TestKVServer_Keys(t *testing.T) {
+
+	ta := require.New(t)
+
+	kvs := KVServer{
+		Storage: map[string]Versions{},
+	}
+
+	for _, k := range []string{"c", "a", "d", "b"} {
+		v, err := kvs.getLockedVersion(&PaxosInstanceId{Key: k})
+		ta.Nil(err)
+		v.mu.Unlock()
+	}
+
+	reply, err := kvs.Keys(nil, &KeyRange{})
+	ta.Nil(err)
+	ta.Equal([]string{"a", "b", "c", "d"}, reply.Keys)
+
+	reply, err = kvs.Keys(nil, &KeyRange{Start: "b", End: "d"})
+	ta.Nil(err)
+	ta.Equal([]string{"b", "c"}, reply.Keys)
+
+	reply, err = kvs.Keys(nil, &KeyRange{Start: "b", Limit: 1})
+	ta.Nil(err)
+	ta.Equal([]string{"b"}, reply.Keys)
+}
@@ -0,0 +1,185 @@
+package paxoskv
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+// AcceptorServer serves a KVServer, along with the health service, on a gRPC
+// server.
+//
+// Other services, e.g., KVService or Admin, can be registered on Server before
+// Start.
+type AcceptorServer struct {
+	Server   *grpc.Server
+	KVServer *KVServer
+	Health   *Health
+
+	// Dir, if not empty, is the data dir the storage of KVServer is opened in
+	// by Start. The server is not serving until the storage is recovered.
+	Dir string
+
+	// GCInterval, if not zero, is how often KVServer.GC runs while serving.
+	// A deleted key is dropped after two intervals.
+	GCInterval time.Duration
+
+	addr string
+
+	mu  sync.Mutex
+	lis net.Listener
+
+	// done is closed when Serve returns, with the error it returns in err.
+	done chan struct{}
+	err  error
+
+	// gc is done when runGC returns.
+	gc sync.WaitGroup
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// NewAcceptorServer creates an AcceptorServer of `kvs` to listen on `addr`.
+// With port 0 in `addr`, e.g., "127.0.0.1:0", a free port is chosen by Start,
+// see Addr.
+func NewAcceptorServer(kvs *KVServer, addr string, opts ...grpc.ServerOption) *AcceptorServer {
+
+	h := NewHealth()
+	s := grpc.NewServer(append(h.ServerOptions(), opts...)...)
+	RegisterPaxosKVServer(s, kvs)
+	h.Register(s)
+	reflection.Register(s)
+
+	return &AcceptorServer{
+		Server:   s,
+		KVServer: kvs,
+		Health:   h,
+		addr:     addr,
+		done:     make(chan struct{}),
+	}
+}
+
+// Start listens and serves in the background. It returns after the storage is
+// recovered, when the server reports it is serving.
+func (a *AcceptorServer) Start() error {
+
+	lis, err := net.Listen("tcp", a.addr)
+	if err != nil {
+		return fmt.Errorf("listen: %s %w", a.addr, err)
+	}
+
+	a.mu.Lock()
+	a.lis = lis
+	a.mu.Unlock()
+
+	go func() {
+		err := a.Server.Serve(lis)
+		// Stop may be called before Serve starts.
+		if err != grpc.ErrServerStopped {
+			a.err = err
+		}
+		close(a.done)
+	}()
+
+	if a.Dir != "" {
+		if err := a.KVServer.Open(a.Dir); err != nil {
+			a.Server.Stop()
+			<-a.done
+			return fmt.Errorf("open storage: %w", err)
+		}
+	}
+
+	if a.GCInterval > 0 {
+		a.gc.Add(1)
+		go a.runGC()
+	}
+
+	a.Health.SetServing(true)
+	a.KVServer.log(LevelInfo, "Acceptor: serving", F("addr", lis.Addr()), F("dir", a.Dir))
+	return nil
+}
+
+// runGC runs KVServer.GC every GCInterval until the server stops serving.
+func (a *AcceptorServer) runGC() {
+
+	defer a.gc.Done()
+
+	t := time.NewTicker(a.GCInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-a.done:
+			return
+		case <-t.C:
+			if dropped := a.KVServer.GC(); len(dropped) > 0 {
+				a.KVServer.log(LevelInfo, "Acceptor: GC", F("dropped", len(dropped)))
+			}
+		}
+	}
+}
+
+// Addr returns the address the server is listening on, or "" before Start.
+func (a *AcceptorServer) Addr() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.lis == nil {
+		return ""
+	}
+	return a.lis.Addr().String()
+}
+
+// Done returns a channel that is closed when the server stops serving, either
+// by Stop, GracefulStop or a failure. See Err.
+func (a *AcceptorServer) Done() <-chan struct{} {
+	return a.done
+}
+
+// Err returns the error that made the server stop serving, after Done is
+// closed. It is nil if the server is stopped by Stop or GracefulStop.
+func (a *AcceptorServer) Err() error {
+	<-a.done
+	return a.err
+}
+
+// Stop reports not serving, closes all connections at once and closes the
+// storage.
+func (a *AcceptorServer) Stop() error {
+	a.Health.SetServing(false)
+	a.Server.Stop()
+	return a.close()
+}
+
+// GracefulStop reports not serving, waits for in-flight requests to finish and
+// closes the storage. A Watch stream never ends by itself, thus Stop may be
+// called meanwhile to stop forcibly.
+func (a *AcceptorServer) GracefulStop() error {
+	a.Health.SetServing(false)
+	a.Server.GracefulStop()
+	return a.close()
+}
+
+// close waits for Serve and GC to return, if the server is started, then
+// flushes and closes the storage, only once.
+func (a *AcceptorServer) close() error {
+
+	a.mu.Lock()
+	started := a.lis != nil
+	a.mu.Unlock()
+
+	if started {
+		<-a.done
+	}
+	a.gc.Wait()
+
+	a.closeOnce.Do(func() {
+		a.closeErr = a.KVServer.Close()
+	})
+	return a.closeErr
+}
@@ -0,0 +1,121 @@
+package paxoskv
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+func TestAcceptorServer(t *testing.T) {
+
+	ta := require.New(t)
+
+	dir := t.TempDir()
+
+	kvs, err := NewKVServer("")
+	ta.Nil(err)
+
+	a := NewAcceptorServer(kvs, "127.0.0.1:0")
+	a.Dir = dir
+	ta.Equal("", a.Addr())
+
+	ta.Nil(a.Start())
+	ta.NotEqual("127.0.0.1:0", a.Addr())
+
+	UseCluster(&Cluster{Addrs: map[int64]string{9: a.Addr()}})
+	defer UseCluster(nil)
+
+	p := &Proposer{Id: &PaxosInstanceId{Key: "foo", Ver: 0}, Bal: &BallotNum{N: 1, ProposerId: 1}}
+	v := p.RunPaxos([]int64{9}, &Value{Vi64: 5})
+	ta.Equal(int64(5), v.Vi64)
+
+	ta.Nil(a.GracefulStop())
+	ta.Nil(a.Err())
+	select {
+	case <-a.Done():
+	default:
+		t.Fatal("Done is not closed after GracefulStop")
+	}
+
+	// stopping again does nothing.
+	ta.Nil(a.Stop())
+
+	// the state is flushed to the data dir.
+	kvs, err = NewKVServer(dir)
+	ta.Nil(err)
+	defer kvs.Close()
+
+	state, err := kvs.Inspect(context.Background(), &PaxosInstanceId{Key: "foo", Ver: 0})
+	ta.Nil(err)
+	ta.Equal(int64(5), state.Val.Vi64)
+}
+
+func TestAcceptorServer_StartError(t *testing.T) {
+
+	ta := require.New(t)
+
+	kvs, err := NewKVServer("")
+	ta.Nil(err)
+
+	a := NewAcceptorServer(kvs, "127.0.0.1:0")
+	ta.Nil(a.Start())
+	defer a.Stop()
+
+	// the address is in use.
+	b := NewAcceptorServer(kvs, a.Addr())
+	ta.NotNil(b.Start())
+	ta.Nil(b.Stop())
+
+	// the data dir is a file.
+	file := filepath.Join(t.TempDir(), "file")
+	ta.Nil(os.WriteFile(file, nil, 0644))
+
+	kvs, err = NewKVServer("")
+	ta.Nil(err)
+
+	c := NewAcceptorServer(kvs, "127.0.0.1:0")
+	c.Dir = file
+	ta.NotNil(c.Start())
+	ta.Nil(c.Err())
+}
+
+func TestServeAcceptors_Error(t *testing.T) {
+
+	ta := require.New(t)
+
+	servers, err := ServeAcceptors([]int64{0})
+	ta.Nil(err)
+	defer servers[0].Stop()
+
+	_, err = ServeAcceptors([]int64{1, 0})
+	ta.NotNil(err)
+
+	// Acceptor-1 started before the failure is stopped.
+	servers, err = ServeAcceptors([]int64{1})
+	ta.Nil(err)
+	servers[0].Stop()
+}
+
+func TestAcceptorServer_GC(t *testing.T) {
+
+	ta := require.New(t)
+
+	kvs, err := NewKVServer("")
+	ta.Nil(err)
+
+	a := NewAcceptorServer(kvs, "127.0.0.1:0")
+	a.GCInterval = 10 * time.Millisecond
+	ta.Nil(a.Start())
+	defer a.Stop()
+
+	_, err = kvs.Commit(nil, &Proposer{Id: &PaxosInstanceId{Key: "foo"}, Bal: &BallotNum{N: 1}, Val: &Value{Deleted: true}})
+	ta.Nil(err)
+
+	ta.Eventually(func() bool {
+		return len(kvs.keysIn(&KeyRange{}).Keys) == 0
+	}, time.Second, 10*time.Millisecond, "deleted key is dropped")
+}
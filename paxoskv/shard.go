@@ -0,0 +1,119 @@
+package paxoskv
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var (
+	InvalidShardMap = errors.New("invalid shard map")
+)
+
+// Shard is a range of keys [Start, End) served by an acceptor group.
+// An empty End means no upper bound.
+type Shard struct {
+	Start   string
+	End     string
+	GroupId int64
+}
+
+// clip returns the part of [start, end) in the shard.
+func (s *Shard) clip(start, end string) (string, string) {
+	if s.Start > start {
+		start = s.Start
+	}
+	if s.End != "" && (end == "" || s.End < end) {
+		end = s.End
+	}
+	return start, end
+}
+
+// ShardMap assigns key ranges to acceptor groups.
+// Every paxos instance of a key runs on the acceptor group of the shard the
+// key is in.
+type ShardMap struct {
+	// Groups maps a group id to the Acceptors in the group.
+	// An Acceptor belongs to at most one group.
+	Groups map[int64][]int64
+
+	// Shards are sorted by Start and cover all keys, from "" to no upper
+	// bound, without overlapping.
+	Shards []*Shard
+}
+
+// NewShardMap creates a ShardMap and checks that shards cover all keys and
+// every shard is assigned to a known group.
+func NewShardMap(groups map[int64][]int64, shards []*Shard) (*ShardMap, error) {
+
+	m := &ShardMap{
+		Groups: groups,
+		Shards: append([]*Shard{}, shards...),
+	}
+	sort.Slice(m.Shards, func(i, j int) bool { return m.Shards[i].Start < m.Shards[j].Start })
+
+	if len(m.Shards) == 0 || m.Shards[0].Start != "" {
+		return nil, fmt.Errorf("%w: shards must start from empty key", InvalidShardMap)
+	}
+
+	for i, s := range m.Shards {
+		if _, found := groups[s.GroupId]; !found {
+			return nil, fmt.Errorf("%w: unknown group %d", InvalidShardMap, s.GroupId)
+		}
+
+		if i == len(m.Shards)-1 {
+			if s.End != "" {
+				return nil, fmt.Errorf("%w: the last shard must not have an End", InvalidShardMap)
+			}
+			break
+		}
+		if s.End == "" || s.End != m.Shards[i+1].Start {
+			return nil, fmt.Errorf("%w: shards are not contiguous at %q", InvalidShardMap, s.End)
+		}
+	}
+
+	owner := map[int64]int64{}
+	for gid, aids := range groups {
+		for _, aid := range aids {
+			if g, found := owner[aid]; found {
+				return nil, fmt.Errorf("%w: Acceptor-%d is in group %d and %d", InvalidShardMap, aid, g, gid)
+			}
+			owner[aid] = gid
+		}
+	}
+
+	return m, nil
+}
+
+// Lookup returns the shard a key is in.
+func (m *ShardMap) Lookup(key string) *Shard {
+	i := sort.Search(len(m.Shards), func(i int) bool { return m.Shards[i].Start > key })
+	return m.Shards[i-1]
+}
+
+// Overlap returns the shards having keys in [start, end), in key order.
+// An empty `end` means no upper bound.
+func (m *ShardMap) Overlap(start, end string) []*Shard {
+	shards := []*Shard{}
+	for _, s := range m.Shards {
+		if s.End != "" && s.End <= start {
+			continue
+		}
+		if end != "" && s.Start >= end {
+			break
+		}
+		shards = append(shards, s)
+	}
+	return shards
+}
+
+// AcceptorIds returns the Acceptors of all groups, e.g., to serve all of them
+// in one process with ServeAcceptors.
+func (m *ShardMap) AcceptorIds() []int64 {
+	ids := []int64{}
+	for _, aids := range m.Groups {
+		ids = append(ids, aids...)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
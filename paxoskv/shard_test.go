@@ -0,0 +1,148 @@
+package paxoskv
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewShardMap(t *testing.T) {
+
+	ta := require.New(t)
+
+	groups := map[int64][]int64{
+		1: {0, 1, 2},
+		2: {3, 4, 5},
+	}
+
+	cases := []struct {
+		groups map[int64][]int64
+		shards []*Shard
+		ok     bool
+	}{
+		{groups, []*Shard{{GroupId: 1}}, true},
+		{groups, []*Shard{{Start: "m", GroupId: 2}, {End: "m", GroupId: 1}}, true},
+		{groups, []*Shard{}, false},
+		{groups, []*Shard{{Start: "a", GroupId: 1}}, false},
+		{groups, []*Shard{{GroupId: 3}}, false},
+		{groups, []*Shard{{End: "m", GroupId: 1}}, false},
+		{groups, []*Shard{{End: "m", GroupId: 1}, {Start: "n", GroupId: 2}}, false},
+		{map[int64][]int64{1: {0, 1}, 2: {1, 2}}, []*Shard{{GroupId: 1}}, false},
+	}
+
+	for i, c := range cases {
+		_, err := NewShardMap(c.groups, c.shards)
+		if c.ok {
+			ta.Nil(err, "%d-th: %v", i, c)
+		} else {
+			ta.True(errors.Is(err, InvalidShardMap), "%d-th: %v", i, c)
+		}
+	}
+}
+
+func TestShardMap_Lookup_Overlap(t *testing.T) {
+
+	ta := require.New(t)
+
+	m, err := NewShardMap(
+		map[int64][]int64{1: {0}, 2: {1}, 3: {2}},
+		[]*Shard{
+			{Start: "", End: "g", GroupId: 1},
+			{Start: "g", End: "p", GroupId: 2},
+			{Start: "p", End: "", GroupId: 3},
+		})
+	ta.Nil(err)
+
+	ta.Equal(int64(1), m.Lookup("").GroupId)
+	ta.Equal(int64(1), m.Lookup("f").GroupId)
+	ta.Equal(int64(2), m.Lookup("g").GroupId)
+	ta.Equal(int64(3), m.Lookup("z").GroupId)
+
+	groupsOf := func(shards []*Shard) []int64 {
+		gids := []int64{}
+		for _, s := range shards {
+			gids = append(gids, s.GroupId)
+		}
+		return gids
+	}
+
+	ta.Equal([]int64{1, 2, 3}, groupsOf(m.Overlap("", "")))
+	ta.Equal([]int64{1, 2}, groupsOf(m.Overlap("a", "p")))
+	ta.Equal([]int64{2, 3}, groupsOf(m.Overlap("g", "q")))
+	ta.Equal([]int64{3}, groupsOf(m.Overlap("x", "")))
+
+	ta.Equal([]int64{0, 1, 2}, m.AcceptorIds())
+}
+
+func TestKVClient_Shards(t *testing.T) {
+
+	ta := require.New(t)
+
+	m, err := NewShardMap(
+		map[int64][]int64{
+			1: {0, 1, 2},
+			2: {3, 4, 5},
+		},
+		[]*Shard{
+			{Start: "", End: "m", GroupId: 1},
+			{Start: "m", End: "", GroupId: 2},
+		})
+	ta.Nil(err)
+
+	// all groups in one process
+	servers, err := ServeAcceptors(m.AcceptorIds())
+	ta.Nil(err)
+	defer func() {
+		for _, s := range servers {
+			s.Stop()
+		}
+	}()
+
+	c := &KVClient{Shards: m, ProposerId: 2}
+
+	for _, k := range []string{"a", "z", "m", "b"} {
+		_, err := c.Set(k, &Value{Vi64: 1})
+		ta.Nil(err)
+	}
+
+	g1 := &KVClient{AcceptorIds: []int64{0, 1, 2}, ProposerId: 3}
+	keys, err := g1.listKeys(g1.AcceptorIds, "", "", 0)
+	ta.Nil(err)
+	ta.Equal([]string{"a", "b"}, keys)
+
+	g2 := &KVClient{AcceptorIds: []int64{3, 4, 5}, ProposerId: 3}
+	keys, err = g2.listKeys(g2.AcceptorIds, "", "", 0)
+	ta.Nil(err)
+	ta.Equal([]string{"m", "z"}, keys)
+
+	err = c.Txn(
+		&TxnOp{Key: "b", Val: &Value{Vi64: 2}},
+		&TxnOp{Key: "z", Val: &Value{Vi64: 2}},
+	)
+	ta.Nil(err, "a transaction across groups")
+
+	records, err := c.Scan("b", "", 0)
+	ta.Nil(err)
+	ta.Equal(3, len(records))
+	ta.Equal("b", records[0].Key)
+	ta.Equal(int64(2), records[0].Val.Vi64)
+	ta.Equal("m", records[1].Key)
+	ta.Equal("z", records[2].Key)
+	ta.Equal(int64(2), records[2].Val.Vi64)
+
+	records, err = c.Scan("", "", 3)
+	ta.Nil(err)
+	ta.Equal(3, len(records))
+}
+
+func TestPrefixEnd(t *testing.T) {
+
+	ta := require.New(t)
+
+	ta.Equal("", prefixEnd(""))
+	ta.Equal("b", prefixEnd("a"))
+	ta.Equal("ab", prefixEnd("aa"))
+	ta.Equal("b", prefixEnd("a\xff"))
+	ta.Equal("", prefixEnd("\xff\xff"))
+}
@@ -0,0 +1,285 @@
+package paxoskv
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// walFile is the name of the write-ahead log in the data dir of an Acceptor.
+const walFile = "paxoskv.wal"
+
+// wal is a write-ahead log of LogEntry.
+// Every entry is a uvarint length followed by the marshaled LogEntry.
+type wal struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+// NewKVServer creates a KVServer storing its state in `dir`.
+// If `dir` is empty, the state is only in memory.
+//
+// Every change of an instance is written to the write-ahead log in `dir` and
+// synced before the Acceptor replies, thus an Acceptor never forgets a promise
+// or a vote across restarts. The log is replayed when the KVServer is created,
+// and rewritten with only the current state.
+func NewKVServer(dir string) (*KVServer, error) {
+
+	s := &KVServer{
+		Storage: map[string]Versions{},
+	}
+	if dir == "" {
+		return s, nil
+	}
+
+	if err := s.Open(dir); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Open replays the write-ahead log in `dir` into an in-memory KVServer and
+// stores the state in `dir` from then on.
+//
+// It is for a server that starts reporting its health before the replay
+// completes. No request must be handled before Open returns.
+func (s *KVServer) Open(dir string) error {
+
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(dir, walFile)
+
+	entries, err := readWAL(path, loggerOr(s.Logger))
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, e := range entries {
+		s.apply(e)
+	}
+	s.log(LevelInfo, "Acceptor: replayed log", F("entries", len(entries)), F("path", path))
+
+	s.wal, err = s.compact(path)
+	return err
+}
+
+// Close flushes and closes the write-ahead log. A KVServer must not be used
+// after Close.
+func (s *KVServer) Close() error {
+	if s.wal == nil {
+		return nil
+	}
+
+	s.wal.mu.Lock()
+	defer s.wal.mu.Unlock()
+
+	if err := s.wal.f.Sync(); err != nil {
+		s.wal.f.Close()
+		return err
+	}
+	return s.wal.f.Close()
+}
+
+// apply updates the in-memory state with a log entry.
+func (s *KVServer) apply(e *LogEntry) {
+	switch {
+	case e.Instance != nil:
+		id := e.Instance.Id
+		rec, found := s.Storage[id.Key]
+		if !found {
+			rec = Versions{}
+			s.Storage[id.Key] = rec
+			s.addKey(id.Key)
+		}
+		a := e.Instance.Acceptor
+		rec[id.Ver] = &Version{
+			acceptor: Acceptor{
+				LastBal:   a.LastBal,
+				Val:       a.Val,
+				VBal:      a.VBal,
+				Committed: a.Committed,
+			},
+		}
+	case e.Drop != nil:
+		delete(s.Storage, e.Drop.Key)
+		delete(s.compacted, e.Drop.Key)
+		s.removeKey(e.Drop.Key)
+	case e.Compact != nil:
+		key, floor := e.Compact.Key, e.Compact.Ver
+		rec := s.Storage[key]
+		for ver := range rec {
+			if ver <= floor {
+				delete(rec, ver)
+			}
+		}
+		if len(rec) == 0 {
+			delete(s.Storage, key)
+			s.removeKey(key)
+		}
+		if s.compacted == nil {
+			s.compacted = map[string]int64{}
+		}
+		if f, found := s.compacted[key]; !found || f < floor {
+			s.compacted[key] = floor
+		}
+	case e.Fence != nil:
+		if e.Fence.Unfence {
+			s.removeFence(e.Fence.Range)
+		} else {
+			s.fences = append(s.fences, e.Fence.Range)
+		}
+	}
+}
+
+// compact writes the current state into a new log, replaces the log at `path`
+// with it, and returns it opened for appending.
+func (s *KVServer) compact(path string) (*wal, error) {
+
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &wal{f: f}
+	entries := []*LogEntry{}
+	for _, r := range s.fences {
+		entries = append(entries, &LogEntry{Fence: &FenceRequest{Range: r}})
+	}
+	compacted := make([]string, 0, len(s.compacted))
+	for key := range s.compacted {
+		compacted = append(compacted, key)
+	}
+	sort.Strings(compacted)
+	for _, key := range compacted {
+		entries = append(entries, &LogEntry{Compact: &PaxosInstanceId{Key: key, Ver: s.compacted[key]}})
+	}
+	for _, key := range s.keys {
+		rec := s.Storage[key]
+		vers := make([]int64, 0, len(rec))
+		for ver := range rec {
+			vers = append(vers, ver)
+		}
+		sort.Slice(vers, func(i, j int) bool { return vers[i] < vers[j] })
+
+		for _, ver := range vers {
+			entries = append(entries, &LogEntry{
+				Instance: &Instance{
+					Id:       &PaxosInstanceId{Key: key, Ver: ver},
+					Acceptor: &rec[ver].acceptor,
+				},
+			})
+		}
+	}
+
+	if err := w.append(entries...); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// persist writes the state of an instance to the log, if there is one.
+// It must be called with the lock of the Version held.
+// It returns an error with code Internal if the log fails.
+func (s *KVServer) persist(id *PaxosInstanceId, a *Acceptor) error {
+	return s.writeLog(&LogEntry{Instance: &Instance{Id: id, Acceptor: a}})
+}
+
+// writeLog writes entries to the log, if there is one.
+// It returns an error with code Internal if the log fails.
+func (s *KVServer) writeLog(entries ...*LogEntry) error {
+	if s.wal == nil {
+		return nil
+	}
+	if err := s.wal.append(entries...); err != nil {
+		s.log(LevelError, "Acceptor: fail to write log", F("err", err))
+		return status.Errorf(codes.Internal, "write log: %v", err)
+	}
+	return nil
+}
+
+// append writes entries and syncs them to disk.
+func (w *wal) append(entries ...*LogEntry) error {
+
+	buf := []byte{}
+	for _, e := range entries {
+		data, err := proto.Marshal(e)
+		if err != nil {
+			return err
+		}
+		l := make([]byte, binary.MaxVarintLen64)
+		buf = append(buf, l[:binary.PutUvarint(l, uint64(len(data)))]...)
+		buf = append(buf, data...)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, err := w.f.Write(buf); err != nil {
+		return err
+	}
+	return w.f.Sync()
+}
+
+// readWAL reads all entries from a log. An absent log has no entry.
+// An incomplete entry at the end, left by a crash while writing, is ignored
+// and reported to `lg`.
+func readWAL(path string, lg Logger) ([]*LogEntry, error) {
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	entries := []*LogEntry{}
+
+	for {
+		n, err := binary.ReadUvarint(r)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		data := make([]byte, n)
+		if _, err := io.ReadFull(r, data); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				lg.Log(LevelWarn, "Acceptor: ignore incomplete log entry at the end", F("path", path))
+				return entries, nil
+			}
+			return nil, err
+		}
+
+		e := &LogEntry{}
+		if err := proto.Unmarshal(data, e); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+}
@@ -0,0 +1,107 @@
+package paxoskv
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestNewKVServer_Recover(t *testing.T) {
+
+	ta := require.New(t)
+
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	s, err := NewKVServer(dir)
+	ta.Nil(err)
+
+	bal := &BallotNum{N: 2, ProposerId: 1}
+	p := &Proposer{Id: &PaxosInstanceId{Key: "foo", Ver: 0}, Bal: bal}
+
+	// foo₀ is chosen, foo₁ is only promised.
+	_, err = s.Prepare(ctx, p)
+	ta.Nil(err)
+	p.Val = &Value{Vi64: 5}
+	_, err = s.Accept(ctx, p)
+	ta.Nil(err)
+	_, err = s.Commit(ctx, p)
+	ta.Nil(err)
+
+	_, err = s.Prepare(ctx, &Proposer{Id: &PaxosInstanceId{Key: "foo", Ver: 1}, Bal: &BallotNum{N: 3, ProposerId: 1}})
+	ta.Nil(err)
+
+	// "bar" is dropped by GC.
+	_, err = s.Commit(ctx, &Proposer{Id: &PaxosInstanceId{Key: "bar"}, Bal: bal, Val: &Value{Deleted: true}})
+	ta.Nil(err)
+	s.GC()
+	ta.Equal([]string{"bar"}, s.GC())
+
+	_, err = s.Fence(ctx, &FenceRequest{Range: &KeyRange{Start: "x"}})
+	ta.Nil(err)
+
+	ta.Nil(s.Close())
+
+	for i := 0; i < 2; i++ {
+		// recover from the log, then from the compacted log.
+		s, err = NewKVServer(dir)
+		ta.Nil(err)
+
+		ta.Equal([]string{"foo"}, s.keys)
+
+		v, err := s.getLockedVersion(&PaxosInstanceId{Key: "foo", Ver: 0})
+		ta.Nil(err)
+		ta.True(v.acceptor.Committed)
+		ta.Equal(int64(5), v.acceptor.Val.Vi64)
+		ta.Equal(int64(2), v.acceptor.VBal.N)
+		v.mu.Unlock()
+
+		v, err = s.getLockedVersion(&PaxosInstanceId{Key: "foo", Ver: 1})
+		ta.Nil(err)
+		ta.Equal(int64(3), v.acceptor.LastBal.N)
+		ta.Nil(v.acceptor.Val)
+		v.mu.Unlock()
+
+		_, err = s.getLockedVersion(&PaxosInstanceId{Key: "xyz"})
+		ta.Equal(codes.FailedPrecondition, status.Code(err))
+
+		_, err = s.getLockedVersion(&PaxosInstanceId{Key: "bar"})
+		ta.Equal(codes.OutOfRange, status.Code(err), "compacted")
+
+		ta.Nil(s.Close())
+	}
+}
+
+func TestNewKVServer_IncompleteEntry(t *testing.T) {
+
+	ta := require.New(t)
+
+	dir := t.TempDir()
+
+	s, err := NewKVServer(dir)
+	ta.Nil(err)
+	_, err = s.Prepare(context.Background(), &Proposer{Id: &PaxosInstanceId{Key: "foo"}, Bal: &BallotNum{N: 2}})
+	ta.Nil(err)
+	ta.Nil(s.Close())
+
+	// a crash while writing an entry of 100 bytes.
+	f, err := os.OpenFile(filepath.Join(dir, walFile), os.O_APPEND|os.O_WRONLY, 0644)
+	ta.Nil(err)
+	_, err = f.Write([]byte{100, 1, 2, 3})
+	ta.Nil(err)
+	ta.Nil(f.Close())
+
+	s, err = NewKVServer(dir)
+	ta.Nil(err)
+	defer s.Close()
+
+	v, err := s.getLockedVersion(&PaxosInstanceId{Key: "foo"})
+	ta.Nil(err)
+	ta.Equal(int64(2), v.acceptor.LastBal.N)
+	v.mu.Unlock()
+}
@@ -0,0 +1,217 @@
+package paxoskv
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// TLS is the mutual TLS configuration of the connections between proposers
+// and Acceptors.
+//
+// A node uses one certificate both as an Acceptor, i.e., a server, and as a
+// proposer, i.e., a client. A peer must present a certificate signed by the CA,
+// with a DNS name or common name of an Acceptor or a peer in the Cluster set by
+// UseCluster. If the Cluster names no certificate, any certificate signed by
+// the CA is accepted.
+//
+// The certificate, the key and the CA are reloaded when any of the files
+// changes, e.g., when the certificate is rotated. Established connections are
+// not affected.
+type TLS struct {
+	CertFile string
+	KeyFile  string
+	CAFile   string
+
+	mu      sync.Mutex
+	modTime [3]time.Time
+	cert    *tls.Certificate
+	roots   *x509.CertPool
+}
+
+// LoadTLS loads the certificate and key of this node and the CA to verify
+// peers with.
+func LoadTLS(certFile, keyFile, caFile string) (*TLS, error) {
+	t := &TLS{CertFile: certFile, KeyFile: keyFile, CAFile: caFile}
+	if _, _, err := t.load(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+var (
+	// tlsMu protects defaultTLS.
+	tlsMu sync.RWMutex
+
+	// defaultTLS is the TLS set by UseTLS.
+	defaultTLS *TLS
+)
+
+// UseTLS makes Proposers and clients connect to Acceptors with TLS `t`, and
+// ServeAcceptors serve with it. A nil `t` disables TLS.
+//
+// Connections are reused, thus it should be called before connecting to any
+// Acceptor.
+func UseTLS(t *TLS) {
+	tlsMu.Lock()
+	defer tlsMu.Unlock()
+	defaultTLS = t
+}
+
+// currentTLS returns the TLS set by UseTLS, or nil.
+func currentTLS() *TLS {
+	tlsMu.RLock()
+	defer tlsMu.RUnlock()
+	return defaultTLS
+}
+
+// dialAcceptor connects to an Acceptor at `addr`, with the TLS set by UseTLS,
+// or in plaintext, sending the token set by UseToken.
+func dialAcceptor(addr string, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	opts = append(opts, tokenDialOptions()...)
+	if t := currentTLS(); t != nil {
+		opts = append(opts, t.DialOption())
+	} else {
+		opts = append(opts, grpc.WithInsecure())
+	}
+	return grpc.Dial(addr, opts...)
+}
+
+// ServerOption returns the option for a grpc.Server of an Acceptor to require
+// a client certificate of a peer in the cluster.
+func (t *TLS) ServerOption() grpc.ServerOption {
+	return grpc.Creds(credentials.NewTLS(&tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			cert, roots, err := t.load()
+			if err != nil {
+				return nil, err
+			}
+			return &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				NextProtos:   []string{"h2"},
+				Certificates: []tls.Certificate{*cert},
+				ClientCAs:    roots,
+				ClientAuth:   tls.RequireAndVerifyClientCert,
+				VerifyConnection: func(cs tls.ConnectionState) error {
+					return checkPeer(cs.PeerCertificates[0])
+				},
+			}, nil
+		},
+	}))
+}
+
+// DialOption returns the option to connect to an Acceptor, presenting the
+// certificate of this node and requiring one of a peer in the cluster.
+func (t *TLS) DialOption() grpc.DialOption {
+	return grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{
+		MinVersion: tls.VersionTLS12,
+		// The server certificate is verified by VerifyConnection, with the
+		// CA reloaded, and by the names in the cluster instead of the address.
+		InsecureSkipVerify: true,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, _, err := t.load()
+			return cert, err
+		},
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			_, roots, err := t.load()
+			if err != nil {
+				return err
+			}
+			if len(cs.PeerCertificates) == 0 {
+				return errors.New("no certificate from the Acceptor")
+			}
+
+			inter := x509.NewCertPool()
+			for _, c := range cs.PeerCertificates[1:] {
+				inter.AddCert(c)
+			}
+			_, err = cs.PeerCertificates[0].Verify(x509.VerifyOptions{
+				Roots:         roots,
+				Intermediates: inter,
+				KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+			})
+			if err != nil {
+				return err
+			}
+			return checkPeer(cs.PeerCertificates[0])
+		},
+	}))
+}
+
+// load returns the certificate and the CA, reloading them if any file has
+// changed since the last load. If reloading fails, the last loaded ones are
+// kept.
+func (t *TLS) load() (*tls.Certificate, *x509.CertPool, error) {
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var modTime [3]time.Time
+	for i, path := range []string{t.CertFile, t.KeyFile, t.CAFile} {
+		st, err := os.Stat(path)
+		if err != nil {
+			return t.keep(err)
+		}
+		modTime[i] = st.ModTime()
+	}
+	if t.cert != nil && modTime == t.modTime {
+		return t.cert, t.roots, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+	if err != nil {
+		return t.keep(err)
+	}
+
+	ca, err := os.ReadFile(t.CAFile)
+	if err != nil {
+		return t.keep(err)
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(ca) {
+		return t.keep(fmt.Errorf("no certificate in CA file: %s", t.CAFile))
+	}
+
+	if t.cert != nil {
+		loggerOr(nil).Log(LevelInfo, "TLS: reloaded certificate", F("cert", t.CertFile), F("ca", t.CAFile))
+	}
+	t.cert, t.roots, t.modTime = &cert, roots, modTime
+	return t.cert, t.roots, nil
+}
+
+// keep returns the last loaded certificate and CA if there are, or `err`.
+// It must be called with mu held.
+func (t *TLS) keep(err error) (*tls.Certificate, *x509.CertPool, error) {
+	if t.cert == nil {
+		return nil, nil, err
+	}
+	loggerOr(nil).Log(LevelWarn, "TLS: fail to reload certificate, keep the last one", F("cert", t.CertFile), F("err", err))
+	return t.cert, t.roots, nil
+}
+
+// checkPeer returns an error if the certificate of a peer has no name in the
+// Cluster set by UseCluster.
+func checkPeer(cert *x509.Certificate) error {
+
+	clusterMu.RLock()
+	c := cluster
+	clusterMu.RUnlock()
+
+	if c == nil || len(c.Names) == 0 && len(c.Peers) == 0 {
+		return nil
+	}
+
+	names := append([]string{cert.Subject.CommonName}, cert.DNSNames...)
+	if c.allowed(names) {
+		return nil
+	}
+	return fmt.Errorf("certificate of %v is not in the cluster", names)
+}
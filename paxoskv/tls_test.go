@@ -0,0 +1,175 @@
+package paxoskv
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+// testCA issues certificates for tests.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	dir  string
+}
+
+func newTestCA(ta *require.Assertions, dir string) *testCA {
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ta.Nil(err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "paxoskv-test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	ta.Nil(err)
+	cert, err := x509.ParseCertificate(der)
+	ta.Nil(err)
+
+	ca := &testCA{cert: cert, key: key, dir: dir}
+	writePEM(ta, ca.caFile(), "CERTIFICATE", der)
+	return ca
+}
+
+func (ca *testCA) caFile() string { return filepath.Join(ca.dir, "ca.crt") }
+
+// issue writes a certificate of `name` and its key to <file>.crt and
+// <file>.key, and returns the paths.
+func (ca *testCA) issue(ta *require.Assertions, file, name string) (string, string) {
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ta.Nil(err)
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	ta.Nil(err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	ta.Nil(err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	ta.Nil(err)
+
+	certFile := filepath.Join(ca.dir, file+".crt")
+	keyFile := filepath.Join(ca.dir, file+".key")
+	writePEM(ta, certFile, "CERTIFICATE", der)
+	writePEM(ta, keyFile, "EC PRIVATE KEY", keyDer)
+	return certFile, keyFile
+}
+
+func writePEM(ta *require.Assertions, path, typ string, der []byte) {
+	ta.Nil(os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0600))
+}
+
+// prepareWith sends a Prepare to `addr` over a new connection.
+func prepareWith(addr string, opt grpc.DialOption) error {
+
+	conn, err := grpc.Dial(addr, opt)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	_, err = NewPaxosKVClient(conn).Prepare(ctx, &Proposer{
+		Id:  &PaxosInstanceId{Key: "foo", Ver: 1},
+		Bal: &BallotNum{N: 1, ProposerId: 1},
+	})
+	return err
+}
+
+func TestTLS(t *testing.T) {
+
+	ta := require.New(t)
+
+	dir := t.TempDir()
+	ca := newTestCA(ta, dir)
+
+	serverCert, serverKey := ca.issue(ta, "acceptor-9", "acceptor-9")
+	clientCert, clientKey := ca.issue(ta, "client", "client")
+	otherCert, otherKey := ca.issue(ta, "other", "other")
+
+	serverTLS, err := LoadTLS(serverCert, serverKey, ca.caFile())
+	ta.Nil(err)
+	clientTLS, err := LoadTLS(clientCert, clientKey, ca.caFile())
+	ta.Nil(err)
+	otherTLS, err := LoadTLS(otherCert, otherKey, ca.caFile())
+	ta.Nil(err)
+
+	_, err = LoadTLS(serverCert, serverKey, filepath.Join(dir, "absent"))
+	ta.NotNil(err)
+
+	kvs, err := NewKVServer("")
+	ta.Nil(err)
+	a := NewAcceptorServer(kvs, "127.0.0.1:0", serverTLS.ServerOption())
+	ta.Nil(a.Start())
+	defer a.Stop()
+
+	UseCluster(&Cluster{
+		Addrs: map[int64]string{9: a.Addr()},
+		Names: map[int64]string{9: "acceptor-9"},
+		Peers: []string{"client"},
+	})
+	defer UseCluster(nil)
+
+	UseTLS(clientTLS)
+	defer UseTLS(nil)
+
+	p := &Proposer{Id: &PaxosInstanceId{Key: "foo", Ver: 0}, Bal: &BallotNum{N: 1, ProposerId: 1}}
+	v := p.RunPaxos([]int64{9}, &Value{Vi64: 5})
+	ta.Equal(int64(5), v.Vi64)
+
+	ta.Nil(prepareWith(a.Addr(), clientTLS.DialOption()))
+	ta.NotNil(prepareWith(a.Addr(), grpc.WithInsecure()), "plaintext is refused")
+	ta.NotNil(prepareWith(a.Addr(), otherTLS.DialOption()), "not in the cluster")
+
+	// rotate the certificate of the Acceptor to one not in the cluster.
+	data, err := os.ReadFile(otherCert)
+	ta.Nil(err)
+	ta.Nil(os.WriteFile(serverCert, data, 0600))
+	data, err = os.ReadFile(otherKey)
+	ta.Nil(err)
+	ta.Nil(os.WriteFile(serverKey, data, 0600))
+	future := time.Now().Add(time.Minute)
+	ta.Nil(os.Chtimes(serverCert, future, future))
+
+	ta.NotNil(prepareWith(a.Addr(), clientTLS.DialOption()), "the client refuses the reloaded certificate")
+
+	// a broken file does not replace the loaded certificate.
+	ta.Nil(os.WriteFile(serverCert, []byte("broken"), 0600))
+	future = future.Add(time.Minute)
+	ta.Nil(os.Chtimes(serverCert, future, future))
+
+	cert, _, err := serverTLS.load()
+	ta.Nil(err)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	ta.Nil(err)
+	ta.Equal("other", leaf.Subject.CommonName)
+}
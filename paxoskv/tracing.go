@@ -0,0 +1,304 @@
+package paxoskv
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/metadata"
+)
+
+// traceparentKey is the gRPC metadata key carrying the trace context of an
+// RPC, in the W3C traceparent format:
+//
+//	00-<32 hex trace id>-<16 hex span id>-01
+const traceparentKey = "traceparent"
+
+// SpanData is a finished span, as exported. The JSON form follows the field
+// names of OTLP/JSON.
+type SpanData struct {
+	TraceId      string            `json:"traceId"`
+	SpanId       string            `json:"spanId"`
+	ParentSpanId string            `json:"parentSpanId,omitempty"`
+	Name         string            `json:"name"`
+	StartTime    int64             `json:"startTimeUnixNano"`
+	EndTime      int64             `json:"endTimeUnixNano"`
+	Attributes   map[string]string `json:"attributes,omitempty"`
+}
+
+// SpanExporter receives every span when it ends.
+type SpanExporter interface {
+	ExportSpan(s *SpanData)
+}
+
+// Tracer creates spans and exports them to Exporter.
+// A nil *Tracer creates no span and costs nothing, it is the default.
+type Tracer struct {
+	Exporter SpanExporter
+}
+
+// NewTracer creates a Tracer exporting spans to `e`.
+func NewTracer(e SpanExporter) *Tracer {
+	return &Tracer{Exporter: e}
+}
+
+// Span is a span being recorded. A nil *Span records nothing.
+type Span struct {
+	tracer *Tracer
+
+	mu   sync.Mutex
+	data SpanData
+}
+
+type spanContextKey struct{}
+
+// spanContext identifies a span, to make it the parent of other spans.
+type spanContext struct {
+	traceId string
+	spanId  string
+}
+
+// Start starts a span as a child of the span in `ctx`, if there is one, and
+// returns a context with the new span in it.
+func (t *Tracer) Start(ctx context.Context, name string) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+
+	s := &Span{tracer: t, data: SpanData{
+		SpanId:    newTraceId(8),
+		Name:      name,
+		StartTime: time.Now().UnixNano(),
+	}}
+
+	if parent, ok := ctx.Value(spanContextKey{}).(spanContext); ok {
+		s.data.TraceId = parent.traceId
+		s.data.ParentSpanId = parent.spanId
+	} else {
+		s.data.TraceId = newTraceId(16)
+	}
+
+	return context.WithValue(ctx, spanContextKey{}, spanContext{s.data.TraceId, s.data.SpanId}), s
+}
+
+// SetAttr sets an attribute of the span.
+func (s *Span) SetAttr(key string, val interface{}) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.data.Attributes == nil {
+		s.data.Attributes = map[string]string{}
+	}
+	s.data.Attributes[key] = fmt.Sprint(val)
+}
+
+// End ends the span and exports it.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	s.data.EndTime = time.Now().UnixNano()
+	d := s.data
+	s.mu.Unlock()
+
+	s.tracer.Exporter.ExportSpan(&d)
+}
+
+// injectTrace returns a context whose outgoing gRPC metadata carries the span
+// in `ctx`, if there is one.
+func injectTrace(ctx context.Context) context.Context {
+	sc, ok := ctx.Value(spanContextKey{}).(spanContext)
+	if !ok {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, traceparentKey, "00-"+sc.traceId+"-"+sc.spanId+"-01")
+}
+
+// extractTrace returns a context with the span carried by the incoming gRPC
+// metadata of `ctx` as the parent, if there is one.
+func extractTrace(ctx context.Context) context.Context {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx
+	}
+	vals := md.Get(traceparentKey)
+	if len(vals) == 0 {
+		return ctx
+	}
+
+	parts := strings.Split(vals[0], "-")
+	if len(parts) != 4 || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return ctx
+	}
+	return context.WithValue(ctx, spanContextKey{}, spanContext{parts[1], parts[2]})
+}
+
+// startSpan starts the span of a request to this Acceptor, as a child of the
+// span of the proposer carried in the gRPC metadata of `c`.
+func (s *KVServer) startSpan(c context.Context, name string, r *Proposer) *Span {
+	t := tracerOr(s.Tracer)
+	if t == nil {
+		return nil
+	}
+
+	_, span := t.Start(extractTrace(c), name)
+	span.SetAttr("acceptor", s.Id)
+	span.SetAttr("key", r.Id.GetKey())
+	span.SetAttr("ver", r.Id.GetVer())
+	span.SetAttr("bal", r.Bal)
+	return span
+}
+
+// newTraceId returns `n` random bytes in hex.
+func newTraceId(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+var (
+	// tracerMu protects defaultTracer.
+	tracerMu sync.RWMutex
+
+	// defaultTracer is used where no Tracer is given.
+	defaultTracer *Tracer
+)
+
+// SetTracer sets the Tracer used where no Tracer is given: by a KVServer or a
+// KVClient whose Tracer is nil, and by the exported methods of Proposer.
+// A nil `t` disables tracing.
+func SetTracer(t *Tracer) {
+	tracerMu.Lock()
+	defer tracerMu.Unlock()
+	defaultTracer = t
+}
+
+// tracerOr returns `t`, or the default Tracer if `t` is nil.
+func tracerOr(t *Tracer) *Tracer {
+	if t != nil {
+		return t
+	}
+
+	tracerMu.RLock()
+	defer tracerMu.RUnlock()
+	return defaultTracer
+}
+
+// JSONExporter writes every span as a line of JSON, e.g., to a file.
+type JSONExporter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewJSONExporter creates a JSONExporter writing to `w`.
+func NewJSONExporter(w io.Writer) *JSONExporter {
+	return &JSONExporter{w: w}
+}
+
+func (e *JSONExporter) ExportSpan(s *SpanData) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.w.Write(append(data, '\n'))
+}
+
+// CollectorExporter posts spans in batches to a collector, as a JSON body:
+//
+//	{"spans": [<SpanData>, ...]}
+//
+// Spans are posted in the background; spans that do not fit in the buffer
+// while the collector is slow are dropped.
+type CollectorExporter struct {
+	URL string
+
+	// mu protects closed and sending to ch.
+	mu     sync.Mutex
+	closed bool
+
+	ch   chan *SpanData
+	done chan struct{}
+}
+
+// collectorBatch is the max number of spans in a post.
+const collectorBatch = 128
+
+// NewCollectorExporter creates a CollectorExporter posting to `url`.
+// Close must be called to post the buffered spans.
+func NewCollectorExporter(url string) *CollectorExporter {
+	e := &CollectorExporter{
+		URL:  url,
+		ch:   make(chan *SpanData, 4096),
+		done: make(chan struct{}),
+	}
+	go e.loop()
+	return e
+}
+
+// ExportSpan buffers a span to post. A span exported after Close is dropped.
+func (e *CollectorExporter) ExportSpan(s *SpanData) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.closed {
+		return
+	}
+	select {
+	case e.ch <- s:
+	default:
+	}
+}
+
+// Close posts the buffered spans and stops the exporter.
+// It is safe to call Close more than once, and along with ExportSpan.
+func (e *CollectorExporter) Close() {
+	e.mu.Lock()
+	if !e.closed {
+		e.closed = true
+		close(e.ch)
+	}
+	e.mu.Unlock()
+
+	<-e.done
+}
+
+func (e *CollectorExporter) loop() {
+	defer close(e.done)
+
+	cli := &http.Client{Timeout: 5 * time.Second}
+
+	for s := range e.ch {
+		batch := []*SpanData{s}
+		for len(batch) < collectorBatch && len(e.ch) > 0 {
+			batch = append(batch, <-e.ch)
+		}
+
+		body, err := json.Marshal(map[string][]*SpanData{"spans": batch})
+		if err != nil {
+			continue
+		}
+		resp, err := cli.Post(e.URL, "application/json", bytes.NewReader(body))
+		if err != nil {
+			loggerOr(nil).Log(LevelWarn, "Tracer: fail to post spans", F("url", e.URL), F("err", err))
+			continue
+		}
+		resp.Body.Close()
+	}
+}
@@ -0,0 +1,182 @@
+package paxoskv
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+// testExporter keeps every span it receives.
+type testExporter struct {
+	mu    sync.Mutex
+	spans []*SpanData
+}
+
+func (e *testExporter) ExportSpan(s *SpanData) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, s)
+}
+
+// named returns the spans with name `name`.
+func (e *testExporter) named(name string) []*SpanData {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	res := []*SpanData{}
+	for _, s := range e.spans {
+		if s.Name == name {
+			res = append(res, s)
+		}
+	}
+	return res
+}
+
+func TestTracing(t *testing.T) {
+
+	ta := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+
+	servers, err := ServeAcceptors(acceptorIds)
+	ta.Nil(err)
+	defer func() {
+		for _, s := range servers {
+			s.Stop()
+		}
+	}()
+
+	e := &testExporter{}
+	SetTracer(NewTracer(e))
+	defer SetTracer(nil)
+
+	p := &Proposer{
+		Id:  &PaxosInstanceId{Key: "traced", Ver: 0},
+		Bal: &BallotNum{N: 0, ProposerId: 2},
+	}
+	p.RunPaxos(acceptorIds, &Value{Vi64: 5})
+
+	roots := e.named("RunPaxos")
+	ta.Equal(1, len(roots))
+	root := roots[0]
+	ta.Equal("", root.ParentSpanId)
+	ta.Equal("traced", root.Attributes["key"])
+	ta.Equal("0", root.Attributes["retries"])
+
+	phase1 := e.named("Phase1")
+	ta.Equal(1, len(phase1))
+	ta.Equal(root.TraceId, phase1[0].TraceId)
+	ta.Equal(root.SpanId, phase1[0].ParentSpanId)
+
+	// the spans of the Acceptors are children of the spans of the RPCs.
+	prepares := e.named("Prepare")
+	ta.Equal(3, len(prepares))
+	bySpanId := map[string]*SpanData{}
+	for _, s := range prepares {
+		ta.Equal(phase1[0].SpanId, s.ParentSpanId)
+		bySpanId[s.SpanId] = s
+	}
+
+	handled := e.named("Acceptor.Prepare")
+	ta.Equal(3, len(handled))
+	for _, s := range handled {
+		ta.Equal(root.TraceId, s.TraceId)
+		parent := bySpanId[s.ParentSpanId]
+		ta.NotNil(parent)
+		ta.Equal(parent.Attributes["acceptor"], s.Attributes["acceptor"])
+		ta.Equal("traced", s.Attributes["key"])
+	}
+
+	ta.Equal(1, len(e.named("Phase2")))
+	ta.Equal(3, len(e.named("Acceptor.Accept")))
+	ta.Equal(3, len(e.named("Acceptor.Commit")))
+}
+
+func TestJSONExporter(t *testing.T) {
+
+	ta := require.New(t)
+
+	buf := &bytes.Buffer{}
+	tr := NewTracer(NewJSONExporter(buf))
+
+	_, s := tr.Start(context.Background(), "foo")
+	s.SetAttr("key", "bar")
+	s.End()
+
+	got := &SpanData{}
+	ta.Nil(json.Unmarshal(buf.Bytes(), got))
+	ta.Equal("foo", got.Name)
+	ta.Equal(32, len(got.TraceId))
+	ta.Equal(16, len(got.SpanId))
+	ta.Equal("bar", got.Attributes["key"])
+	ta.True(got.EndTime >= got.StartTime)
+}
+
+func TestCollectorExporter(t *testing.T) {
+
+	ta := require.New(t)
+
+	mu := sync.Mutex{}
+	received := []*SpanData{}
+
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := map[string][]*SpanData{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		received = append(received, body["spans"]...)
+		mu.Unlock()
+	}))
+	defer collector.Close()
+
+	e := NewCollectorExporter(collector.URL)
+	tr := NewTracer(e)
+
+	ctx, parent := tr.Start(context.Background(), "parent")
+	_, child := tr.Start(ctx, "child")
+	child.End()
+	parent.End()
+
+	e.Close()
+
+	// spans ended after Close are dropped.
+	_, late := tr.Start(context.Background(), "late")
+	ta.NotPanics(late.End)
+	e.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	ta.Equal(2, len(received))
+	ta.Equal("child", received[0].Name)
+	ta.Equal(received[1].SpanId, received[0].ParentSpanId)
+}
+
+func TestCollectorExporter_CloseWhileExporting(t *testing.T) {
+
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer collector.Close()
+
+	e := NewCollectorExporter(collector.URL)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				e.ExportSpan(&SpanData{Name: "x"})
+			}
+		}()
+	}
+
+	e.Close()
+	wg.Wait()
+}
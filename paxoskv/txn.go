@@ -0,0 +1,193 @@
+package paxoskv
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"golang.org/x/net/context"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	TxnAborted   = errors.New("transaction aborted")
+	DuplicateKey = errors.New("duplicate key in transaction")
+	InvalidTxnOp = errors.New("invalid transaction op")
+)
+
+const (
+	// txnKeyPrefix is the key prefix of transaction records.
+	// A transaction record is version 0 of key txnKeyPrefix + TxnId. It is
+	// tombstoned at version 1 once no intent needs it.
+	txnKeyPrefix = internalKeyPrefix + "txn/"
+
+	// The value of a transaction record, in Vi64.
+	txnCommitted = int64(1)
+	txnAborted   = int64(2)
+)
+
+// TxnOp is a write in a transaction.
+// To delete a key in a transaction, use a tombstone as `Val`.
+type TxnOp struct {
+	Key string
+	Val *Value
+}
+
+// Txn writes several keys atomically: either all of the ops become visible,
+// or none of them does.
+//
+// A transaction is done in two phases:
+//
+// - Write an intent, a Value with `TxnId` set, as the next version of every
+// key in the ops.
+//
+// - Choose the transaction record, version 0 of key txnKeyPrefix + TxnId, to
+// be committed, by running a paxos on it.
+//
+// A writer that meets an intent tries to choose the transaction record to be
+// aborted, before writing a version over it. Since a paxos instance chooses
+// only one value, a transaction is either committed, with all its intents
+// visible, or aborted, with all its intents ignored by readers. A reader skips
+// an intent of a transaction not yet decided, without aborting it.
+//
+// Thus a transaction is aborted if a writer of one of its keys meets its
+// intent before it commits, in which case a TxnAborted error is returned.
+//
+// After the transaction is decided, every intent is resolved, see resolveTxn,
+// and the transaction record is tombstoned, to be dropped by GC.
+func (c *KVClient) Txn(ops ...*TxnOp) error {
+
+	for _, op := range ops {
+		if op == nil {
+			return fmt.Errorf("%w: nil op", InvalidTxnOp)
+		}
+		if op.Val == nil {
+			return fmt.Errorf("%w: no value for %q", InvalidTxnOp, op.Key)
+		}
+	}
+
+	ops = append([]*TxnOp{}, ops...)
+	sort.Slice(ops, func(i, j int) bool { return ops[i].Key < ops[j].Key })
+	for i := 1; i < len(ops); i++ {
+		if ops[i].Key == ops[i-1].Key {
+			return DuplicateKey
+		}
+	}
+
+	ctx := context.Background()
+	txnId := c.newId()
+	vers := make([]int64, len(ops))
+
+	for i, op := range ops {
+		intent := proto.Clone(op.Val).(*Value)
+		intent.TxnId = txnId
+
+		_, _, ver, err := c.view(ctx, op.Key, true)
+		if err != nil {
+			return err
+		}
+		vers[i], err = c.setFrom(ctx, op.Key, ver+1, intent)
+		if err != nil {
+			return err
+		}
+		c.log(LevelDebug, "KVClient: txn wrote intent", F("txn", txnId), F("key", op.Key), F("ver", vers[i]))
+	}
+
+	v, err := c.runPaxos(ctx, txnKeyPrefix+txnId, 0, &Value{Vi64: txnCommitted})
+	if err != nil {
+		return err
+	}
+	committed := v.Vi64 == txnCommitted
+
+	if err := c.resolveTxn(ctx, txnId, ops, vers, committed); err != nil {
+		return err
+	}
+
+	if !committed {
+		return TxnAborted
+	}
+	return nil
+}
+
+// resolveTxn writes, as the version after every intent of a decided
+// transaction, the value the intent resolves to: the value of the op if the
+// transaction is committed, or the value visible before the intent if it is
+// aborted. A reader stops at it and never looks up the transaction record.
+// Thus Watch and History see a committed op twice, as the intent and as the
+// resolved value, until the record is dropped.
+//
+// If every intent is resolved, the transaction record is tombstoned. If the
+// version after an intent is taken by an intent of another transaction, which
+// may be aborted and let a reader walk down to this one, the record is kept.
+func (c *KVClient) resolveTxn(ctx context.Context, txnId string, ops []*TxnOp, vers []int64, committed bool) error {
+
+	resolved := true
+
+	for i, op := range ops {
+		var val *Value
+		if committed {
+			val = proto.Clone(op.Val).(*Value)
+			val.TxnId = ""
+		} else {
+			var err error
+			val, _, err = c.visible(ctx, op.Key, nil, vers[i]-1, true)
+			if err != nil {
+				return err
+			}
+			if val == nil {
+				val = &Value{Deleted: true}
+			}
+		}
+
+		v, err := c.runPaxos(ctx, op.Key, vers[i]+1, val)
+		if err != nil {
+			return err
+		}
+		if !proto.Equal(v, val) && v.TxnId != "" {
+			c.log(LevelDebug, "KVClient: txn intent is followed by another intent", F("txn", txnId), F("key", op.Key), F("ver", vers[i]))
+			resolved = false
+		}
+	}
+
+	if !resolved {
+		return nil
+	}
+	if _, err := c.runPaxos(ctx, txnKeyPrefix+txnId, 1, &Value{Deleted: true}); err != nil {
+		return err
+	}
+	c.log(LevelDebug, "KVClient: txn resolved", F("txn", txnId), F("committed", committed))
+	return nil
+}
+
+// txnCommitted returns whether a transaction is committed.
+// If the transaction record is not yet chosen, it chooses it to be aborted.
+func (c *KVClient) txnCommitted(ctx context.Context, txnId string) (bool, error) {
+	v, err := c.runPaxos(ctx, txnKeyPrefix+txnId, 0, &Value{Vi64: txnAborted})
+	if err != nil {
+		return false, err
+	}
+	return v.Vi64 == txnCommitted, nil
+}
+
+// txnSeenCommitted returns whether a transaction is committed, without
+// deciding it: a transaction not yet decided is not committed.
+func (c *KVClient) txnSeenCommitted(ctx context.Context, txnId string) (bool, error) {
+	v, err := c.runPaxos(ctx, txnKeyPrefix+txnId, 0, nil)
+	if err != nil {
+		return false, err
+	}
+	return v != nil && v.Vi64 == txnCommitted, nil
+}
+
+// txnDecided returns whether a transaction is decided and whether it is
+// committed, without deciding it.
+func (c *KVClient) txnDecided(ctx context.Context, txnId string) (decided, committed bool, err error) {
+	v, err := c.runPaxos(ctx, txnKeyPrefix+txnId, 0, nil)
+	if err != nil {
+		return false, false, err
+	}
+	if v == nil {
+		return false, false, nil
+	}
+	return true, v.Vi64 == txnCommitted, nil
+}
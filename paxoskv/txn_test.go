@@ -0,0 +1,213 @@
+package paxoskv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+func TestKVClient_Txn(t *testing.T) {
+
+	ta := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+
+	servers, err := ServeAcceptors(acceptorIds)
+	ta.Nil(err)
+	defer func() {
+		for _, s := range servers {
+			s.Stop()
+		}
+	}()
+
+	c := &KVClient{AcceptorIds: acceptorIds, ProposerId: 2}
+
+	_, err = c.Set("record", &Value{Vi64: 1})
+	ta.Nil(err)
+
+	err = c.Txn(
+		&TxnOp{Key: "record", Val: &Value{Vi64: 2}},
+		&TxnOp{Key: "index", Val: &Value{Vi64: 20}},
+	)
+	ta.Nil(err)
+
+	// the intent is followed by its resolved value.
+
+	v, ver, err := c.Get("record")
+	ta.Nil(err)
+	ta.Equal(int64(2), v.Vi64)
+	ta.Equal(int64(2), ver)
+	ta.Equal("", v.TxnId)
+
+	v, ver, err = c.Get("index")
+	ta.Nil(err)
+	ta.Equal(int64(20), v.Vi64)
+	ta.Equal(int64(1), ver)
+
+	err = c.Txn(
+		&TxnOp{Key: "record", Val: &Value{Deleted: true}},
+		&TxnOp{Key: "index", Val: &Value{Vi64: 21}},
+	)
+	ta.Nil(err)
+
+	_, _, err = c.Get("record")
+	ta.Equal(NotFound, err, "deleted in a txn")
+
+	err = c.Txn(
+		&TxnOp{Key: "index", Val: &Value{Vi64: 22}},
+		&TxnOp{Key: "index", Val: &Value{Vi64: 23}},
+	)
+	ta.Equal(DuplicateKey, err)
+
+	err = c.Txn(&TxnOp{Key: "index", Val: &Value{Vi64: 22}}, nil)
+	ta.ErrorIs(err, InvalidTxnOp)
+	err = c.Txn(&TxnOp{Key: "index"})
+	ta.ErrorIs(err, InvalidTxnOp)
+
+	// the resolved transaction records are dropped by GC.
+
+	txnKeys := func() []string {
+		return servers[0].KVServer.keysIn(&KeyRange{Start: txnKeyPrefix, End: prefixEnd(txnKeyPrefix)}).Keys
+	}
+	ta.Equal(2, len(txnKeys()))
+
+	for _, s := range servers {
+		s.KVServer.GC()
+		s.KVServer.GC()
+	}
+	ta.Equal(0, len(txnKeys()))
+
+	v, _, err = c.Get("index")
+	ta.Nil(err)
+	ta.Equal(int64(21), v.Vi64)
+
+	records, err := c.History("index", 0, -1)
+	ta.Nil(err)
+	vals := []int64{}
+	for _, r := range records {
+		vals = append(vals, r.Val.Vi64)
+	}
+	ta.Equal([]int64{20, 21}, vals, "only the resolved values")
+}
+
+func TestKVClient_Txn_abortedByWriter(t *testing.T) {
+
+	ta := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+
+	servers, err := ServeAcceptors(acceptorIds)
+	ta.Nil(err)
+	defer func() {
+		for _, s := range servers {
+			s.Stop()
+		}
+	}()
+
+	c := &KVClient{AcceptorIds: acceptorIds, ProposerId: 2}
+
+	_, err = c.Set("index", &Value{Vi64: 1})
+	ta.Nil(err)
+
+	// A transaction wrote its intent but has not yet committed.
+
+	ctx := context.Background()
+
+	ver, err := c.setFrom(ctx, "index", 1, &Value{Vi64: 2, TxnId: "t1"})
+	ta.Nil(err)
+	ta.Equal(int64(1), ver)
+
+	// A reader skips the intent without aborting the transaction.
+
+	v, ver, err := c.Get("index")
+	ta.Nil(err)
+	ta.Equal(int64(1), v.Vi64)
+	ta.Equal(int64(0), ver)
+	v, err = c.runPaxos(ctx, txnKeyPrefix+"t1", 0, nil)
+	ta.Nil(err)
+	ta.Nil(v, "not decided")
+
+	// A writer aborts it before writing over the intent.
+
+	ver, err = c.Set("index", &Value{Vi64: 3})
+	ta.Nil(err)
+	ta.Equal(int64(2), ver)
+
+	v, err = c.runPaxos(ctx, txnKeyPrefix+"t1", 0, &Value{Vi64: txnCommitted})
+	ta.Nil(err)
+	ta.Equal(txnAborted, v.Vi64, "fails to commit")
+
+	v, _, err = c.Get("index")
+	ta.Nil(err)
+	ta.Equal(int64(3), v.Vi64)
+
+	// The aborted intent is followed by a version already, nothing to
+	// resolve.
+
+	err = c.resolveTxn(ctx, "t1", []*TxnOp{{Key: "index", Val: &Value{Vi64: 2}}}, []int64{1}, false)
+	ta.Nil(err)
+	v, err = c.runPaxos(ctx, txnKeyPrefix+"t1", 1, nil)
+	ta.Nil(err)
+	ta.True(v.Deleted, "record is tombstoned")
+
+	v, ver, err = c.Get("index")
+	ta.Nil(err)
+	ta.Equal(int64(3), v.Vi64)
+	ta.Equal(int64(2), ver)
+
+	// An intent skipped by a reader is visible once committed.
+
+	_, err = c.setFrom(ctx, "index", 3, &Value{Vi64: 4, TxnId: "t2"})
+	ta.Nil(err)
+	v, _, err = c.Get("index")
+	ta.Nil(err)
+	ta.Equal(int64(3), v.Vi64)
+
+	_, err = c.runPaxos(ctx, txnKeyPrefix+"t2", 0, &Value{Vi64: txnCommitted})
+	ta.Nil(err)
+	v, ver, err = c.Get("index")
+	ta.Nil(err)
+	ta.Equal(int64(4), v.Vi64)
+	ta.Equal(int64(3), ver)
+}
+
+func TestKVClient_Txn_resolveAborted(t *testing.T) {
+
+	ta := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+
+	servers, err := ServeAcceptors(acceptorIds)
+	ta.Nil(err)
+	defer func() {
+		for _, s := range servers {
+			s.Stop()
+		}
+	}()
+
+	c := &KVClient{AcceptorIds: acceptorIds, ProposerId: 2}
+
+	_, err = c.Set("index", &Value{Vi64: 1})
+	ta.Nil(err)
+
+	ctx := context.Background()
+	_, err = c.setFrom(ctx, "index", 1, &Value{Vi64: 2, TxnId: "t1"})
+	ta.Nil(err)
+	_, err = c.runPaxos(ctx, txnKeyPrefix+"t1", 0, &Value{Vi64: txnAborted})
+	ta.Nil(err)
+
+	// The aborted intent resolves to the value before it.
+
+	err = c.resolveTxn(ctx, "t1", []*TxnOp{{Key: "index", Val: &Value{Vi64: 2}}}, []int64{1}, false)
+	ta.Nil(err)
+
+	v, ver, err := c.Get("index")
+	ta.Nil(err)
+	ta.Equal(int64(1), v.Vi64)
+	ta.Equal(int64(2), ver)
+
+	v, err = c.runPaxos(ctx, txnKeyPrefix+"t1", 1, nil)
+	ta.Nil(err)
+	ta.True(v.Deleted, "record is tombstoned")
+}
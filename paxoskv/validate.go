@@ -0,0 +1,73 @@
+package paxoskv
+
+import (
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// MaxValueSize is the max size in bytes of a marshaled Value. A larger one is
+// refused by Acceptors and KVService.
+const MaxValueSize = 64 << 10
+
+// checkProposer returns an InvalidArgument error if a Prepare, Accept or Commit
+// request is malformed: without an instance id or a ballot, with a negative
+// version, or with a value larger than MaxValueSize.
+func checkProposer(r *Proposer) error {
+	if r == nil {
+		return status.Error(codes.InvalidArgument, "no request")
+	}
+	if err := checkInstanceId(r.Id); err != nil {
+		return err
+	}
+	if r.Bal == nil {
+		return status.Error(codes.InvalidArgument, "no ballot")
+	}
+	return checkValue(r.Val)
+}
+
+// checkAccept is the same as checkProposer for an Accept request, which also
+// must have a value: a vote for nothing at a higher ballot would erase the
+// earlier vote, which may be of a chosen value.
+func checkAccept(r *Proposer) error {
+	if err := checkProposer(r); err != nil {
+		return err
+	}
+	if r.Val == nil {
+		return status.Error(codes.InvalidArgument, "no value to accept")
+	}
+	return nil
+}
+
+// checkCommit is the same as checkProposer for a Commit request, which also
+// must have a value: the value in it is stored as chosen for ever.
+func checkCommit(r *Proposer) error {
+	if err := checkProposer(r); err != nil {
+		return err
+	}
+	if r.Val == nil {
+		return status.Error(codes.InvalidArgument, "no value to commit")
+	}
+	return nil
+}
+
+// checkInstanceId returns an InvalidArgument error if an instance id is absent
+// or has a negative version.
+func checkInstanceId(id *PaxosInstanceId) error {
+	if id == nil {
+		return status.Error(codes.InvalidArgument, "no instance id")
+	}
+	if id.Ver < 0 {
+		return status.Errorf(codes.InvalidArgument, "negative version: %d", id.Ver)
+	}
+	return nil
+}
+
+// checkValue returns an InvalidArgument error if a Value is larger than
+// MaxValueSize.
+func checkValue(v *Value) error {
+	if size := proto.Size(v); size > MaxValueSize {
+		return status.Errorf(codes.InvalidArgument, "value too large: %d > %d bytes", size, MaxValueSize)
+	}
+	return nil
+}
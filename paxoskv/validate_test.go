@@ -0,0 +1,156 @@
+package paxoskv
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestKVServer_InvalidArgument(t *testing.T) {
+
+	ta := require.New(t)
+
+	kvs, err := NewKVServer("")
+	ta.Nil(err)
+
+	id := &PaxosInstanceId{Key: "x", Ver: 0}
+	bal := &BallotNum{N: 1, ProposerId: 1}
+	huge := &Value{TxnId: strings.Repeat("x", MaxValueSize)}
+
+	handlers := map[string]func(context.Context, *Proposer) (*Acceptor, error){
+		"Prepare": kvs.Prepare,
+		"Accept":  kvs.Accept,
+		"Commit":  kvs.Commit,
+	}
+
+	for _, p := range []*Proposer{
+		nil,
+		{Bal: bal},
+		{Id: id},
+		{Id: &PaxosInstanceId{Key: "x", Ver: -1}, Bal: bal},
+		{Id: id, Bal: bal, Val: huge},
+	} {
+		for name, h := range handlers {
+			reply, err := h(nil, p)
+			ta.Nil(reply, "%s %v", name, p)
+			ta.Equal(codes.InvalidArgument, status.Code(err), "%s %v", name, p)
+		}
+	}
+
+	// a Commit stores its value as chosen, it must have one.
+	reply, err := kvs.Commit(nil, &Proposer{Id: id, Bal: bal})
+	ta.Nil(reply)
+	ta.Equal(codes.InvalidArgument, status.Code(err))
+
+	// an Accept without a value would erase the earlier vote.
+	reply, err = kvs.Accept(nil, &Proposer{Id: id, Bal: bal})
+	ta.Nil(reply)
+	ta.Equal(codes.InvalidArgument, status.Code(err))
+
+	admin := NewAdminService(kvs, "")
+	for _, id := range []*PaxosInstanceId{nil, {Key: "x", Ver: -1}} {
+		_, err = kvs.Inspect(nil, id)
+		ta.Equal(codes.InvalidArgument, status.Code(err))
+		_, err = admin.GetInstance(nil, id)
+		ta.Equal(codes.InvalidArgument, status.Code(err))
+	}
+
+	// nothing is stored by an invalid request.
+	ta.Equal(0, len(kvs.Storage))
+
+	svc := NewKVService(&KVClient{})
+	_, err = svc.Put(nil, &Record{Key: "x", Val: huge})
+	ta.Equal(codes.InvalidArgument, status.Code(err))
+}
+
+func FuzzKVServer(f *testing.F) {
+
+	huge := strings.Repeat("t", MaxValueSize)
+
+	// handler: 0 Prepare, 1 Accept, 2 Commit, 3 Inspect.
+	// flags: 1 nil request, 2 no id, 4 no ballot, 8 no value, 16 deleted.
+	for _, h := range []uint8{0, 1, 2, 3} {
+		f.Add(h, uint8(0), "x", int64(0), int64(1), int64(1), int64(5), "")
+		f.Add(h, uint8(1), "x", int64(0), int64(1), int64(1), int64(5), "")
+		f.Add(h, uint8(2), "x", int64(0), int64(1), int64(1), int64(5), "")
+		f.Add(h, uint8(4), "x", int64(0), int64(1), int64(1), int64(5), "")
+		f.Add(h, uint8(8), "x", int64(0), int64(1), int64(1), int64(5), "")
+		f.Add(h, uint8(16), "x", int64(1), int64(1), int64(1), int64(0), "")
+		f.Add(h, uint8(0), "x", int64(-1), int64(1), int64(1), int64(5), "")
+		f.Add(h, uint8(0), "x", int64(0), int64(-3), int64(-1), int64(5), "")
+		f.Add(h, uint8(0), "", int64(0), int64(1), int64(1), int64(5), "")
+		f.Add(h, uint8(0), "\x00txn/t", int64(0), int64(1), int64(1), int64(5), "")
+		f.Add(h, uint8(0), "x", int64(0), int64(1), int64(1), int64(5), huge)
+		f.Add(h, uint8(0), "x", int64(1)<<62, int64(1)<<62, int64(1), int64(5), huge[:MaxValueSize-8])
+	}
+
+	kvs, err := NewKVServer("")
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	handlers := []func(context.Context, *Proposer) (*Acceptor, error){
+		kvs.Prepare,
+		kvs.Accept,
+		kvs.Commit,
+		func(c context.Context, r *Proposer) (*Acceptor, error) {
+			return kvs.Inspect(c, r.GetId())
+		},
+	}
+	checks := []func(*Proposer) error{
+		checkProposer,
+		checkAccept,
+		checkCommit,
+		func(r *Proposer) error {
+			return checkInstanceId(r.GetId())
+		},
+	}
+
+	f.Fuzz(func(t *testing.T, h uint8, flags uint8, key string, ver, n, proposerId, vi64 int64, txnId string) {
+
+		h %= uint8(len(handlers))
+
+		var p *Proposer
+		if flags&1 == 0 {
+			p = &Proposer{}
+			if flags&2 == 0 {
+				p.Id = &PaxosInstanceId{Key: key, Ver: ver}
+			}
+			if flags&4 == 0 {
+				p.Bal = &BallotNum{N: n, ProposerId: proposerId}
+			}
+			if flags&8 == 0 {
+				p.Val = &Value{Vi64: vi64, Deleted: flags&16 != 0, TxnId: txnId}
+			}
+		}
+
+		reply, err := handlers[h](nil, p)
+
+		if err != nil {
+			if h == 3 && status.Code(err) == codes.NotFound {
+				return
+			}
+			if status.Code(err) != codes.InvalidArgument {
+				t.Fatalf("handler %d %v: unexpected error: %v", h, p, err)
+			}
+			if checks[h](p) == nil {
+				t.Fatalf("handler %d %v: refused a valid request: %v", h, p, err)
+			}
+			return
+		}
+
+		if err := checks[h](p); err != nil {
+			t.Fatalf("handler %d %v: accepted an invalid request: %v", h, p, err)
+		}
+		if reply == nil {
+			t.Fatalf("handler %d %v: no reply", h, p)
+		}
+		if _, found := AcceptorStatus_name[int32(reply.Status)]; !found {
+			t.Fatalf("handler %d %v: unknown status: %v", h, p, reply.Status)
+		}
+	})
+}
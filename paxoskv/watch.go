@@ -0,0 +1,355 @@
+package paxoskv
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// watchBuffer is the number of records an Acceptor buffers for a watcher.
+// A watcher falling behind more than that is disconnected.
+const watchBuffer = 1024
+
+// watcher is a Watch request being served by an Acceptor.
+type watcher struct {
+	req *WatchRequest
+	ch  chan *Record
+}
+
+func (w *watcher) match(key string) bool {
+	if w.req.Prefix {
+		return strings.HasPrefix(key, w.req.Key)
+	}
+	return key == w.req.Key
+}
+
+// Watch handles Watch request.
+// It first sends the committed versions since r.FromVer of the watched keys,
+// then every version this Acceptor learns by Commit, until the stream ends.
+//
+// A version committed while Watch starts may be sent twice.
+func (s *KVServer) Watch(r *WatchRequest, stream PaxosKV_WatchServer) error {
+
+	s.log(LevelDebug, "Acceptor: recv Watch-request", F("key", r.Key), F("prefix", r.Prefix), F("ver", r.FromVer))
+
+	if err := s.ACL.check(stream.Context(), r.Key, PermRead); err != nil {
+		return err
+	}
+
+	w := &watcher{req: r, ch: make(chan *Record, watchBuffer)}
+
+	s.watchMu.Lock()
+	if s.watchers == nil {
+		s.watchers = map[*watcher]bool{}
+	}
+	s.watchers[w] = true
+	s.watchMu.Unlock()
+
+	defer func() {
+		s.watchMu.Lock()
+		delete(s.watchers, w)
+		s.watchMu.Unlock()
+	}()
+
+	for _, rec := range s.committed(w) {
+		if err := stream.Send(rec); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case rec, ok := <-w.ch:
+			if !ok {
+				return status.Error(codes.ResourceExhausted, "watcher falls behind")
+			}
+			if err := stream.Send(rec); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// committed returns the committed versions a watcher watches, in key and
+// version order.
+func (s *KVServer) committed(w *watcher) []*Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records := []*Record{}
+
+	// a watched key always has w.req.Key as prefix.
+	i := sort.SearchStrings(s.keys, w.req.Key)
+	for ; i < len(s.keys); i++ {
+		key := s.keys[i]
+		if !strings.HasPrefix(key, w.req.Key) {
+			break
+		}
+		if !w.match(key) {
+			continue
+		}
+
+		rec := s.Storage[key]
+		vers := make([]int64, 0, len(rec))
+		for ver := range rec {
+			if ver >= w.req.FromVer {
+				vers = append(vers, ver)
+			}
+		}
+		sort.Slice(vers, func(i, j int) bool { return vers[i] < vers[j] })
+
+		for _, ver := range vers {
+			v := rec[ver]
+			v.mu.Lock()
+			if v.acceptor.Committed {
+				records = append(records, &Record{Key: key, Ver: ver, Val: v.acceptor.Val, Bal: v.acceptor.VBal})
+			}
+			v.mu.Unlock()
+		}
+	}
+
+	return records
+}
+
+// notify sends a newly learnt version to the watchers of it.
+// A watcher whose buffer is full is closed.
+func (s *KVServer) notify(rec *Record) {
+	s.watchMu.Lock()
+	defer s.watchMu.Unlock()
+
+	for w := range s.watchers {
+		if !w.match(rec.Key) {
+			continue
+		}
+		select {
+		case w.ch <- rec:
+		default:
+			s.log(LevelWarn, "Acceptor: watcher falls behind, close it", F("key", w.req.Key), F("prefix", w.req.Prefix))
+			close(w.ch)
+			delete(s.watchers, w)
+		}
+	}
+}
+
+// Watch returns a channel of the versions since `fromVer` of a key, or of all
+// keys with the prefix `key` if `prefix` is true. The versions of a key are
+// delivered in version order, each once, as Acceptors learn them.
+// Versions written by transactions that are aborted are skipped; a version
+// written by a transaction not yet decided is delivered, with the later versions
+// of the key, once the transaction commits.
+//
+// It watches all Acceptors of the groups serving the watched keys and requires
+// a quorum of every group to start. A version that no Acceptor has learnt is
+// read with paxos when a later version of the same key arrives.
+//
+// The channel is closed when `ctx` is done or all the Acceptors end the watch.
+func (c *KVClient) Watch(ctx context.Context, key string, prefix bool, fromVer int64) (<-chan *Record, error) {
+
+	if fromVer < 0 {
+		fromVer = 0
+	}
+	req := &WatchRequest{Key: key, Prefix: prefix, FromVer: fromVer}
+
+	m := c.shardMap()
+	shards := []*Shard{m.Lookup(key)}
+	if prefix {
+		shards = m.Overlap(key, prefixEnd(key))
+	}
+
+	groups := map[int64]bool{}
+	in := make(chan *Record)
+	wg := sync.WaitGroup{}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	for _, shard := range shards {
+		if groups[shard.GroupId] {
+			continue
+		}
+		groups[shard.GroupId] = true
+
+		acceptorIds := m.Groups[shard.GroupId]
+		quorum := len(acceptorIds)/2 + 1
+		ok := 0
+
+		for _, aid := range acceptorIds {
+			address := acceptorAddr(aid)
+			conn, err := dialAcceptor(address)
+			if err != nil {
+				c.log(LevelWarn, "KVClient: fail to connect", F("acceptor", aid), F("err", err))
+				continue
+			}
+
+			stream, err := NewPaxosKVClient(conn).Watch(ctx, req)
+			if err != nil {
+				c.log(LevelWarn, "KVClient: Watch failure", F("acceptor", aid), F("err", err))
+				conn.Close()
+				continue
+			}
+
+			ok++
+			wg.Add(1)
+			go func(aid int64) {
+				defer wg.Done()
+				defer conn.Close()
+				for {
+					rec, err := stream.Recv()
+					if err != nil {
+						c.log(LevelDebug, "KVClient: Watch ends", F("acceptor", aid), F("err", err))
+						return
+					}
+					select {
+					case in <- rec:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}(aid)
+		}
+
+		if ok < quorum {
+			cancel()
+			return nil, NotEnoughQuorum
+		}
+	}
+
+	go func() {
+		wg.Wait()
+		close(in)
+	}()
+
+	out := make(chan *Record)
+	go func() {
+		c.deliver(ctx, in, out, fromVer)
+		cancel()
+	}()
+
+	return out, nil
+}
+
+// txnPollInterval is how often Watch looks up the transactions of the intents
+// it holds back.
+const txnPollInterval = 50 * time.Millisecond
+
+// deliver sends records from `in` to `out`, in version order per key, skipping
+// duplicates and filling in the versions missed.
+//
+// An intent of a transaction not yet decided is held back, with the later
+// versions of its key, until the transaction is decided by someone else:
+// Watch only reads and never aborts a transaction.
+func (c *KVClient) deliver(ctx context.Context, in <-chan *Record, out chan<- *Record, fromVer int64) {
+
+	defer close(out)
+
+	// next is the next version to receive of every key.
+	next := map[string]int64{}
+
+	// pending is the records received but not yet delivered of every key.
+	pending := map[string][]*Record{}
+
+	ticker := time.NewTicker(txnPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for key := range pending {
+				if err := c.flush(ctx, pending, key, out); err != nil {
+					return
+				}
+			}
+		case rec, ok := <-in:
+			if !ok {
+				return
+			}
+
+			if strings.HasPrefix(rec.Key, internalKeyPrefix) {
+				continue
+			}
+
+			n, found := next[rec.Key]
+			if !found {
+				n = fromVer
+			}
+			if rec.Ver < n {
+				continue
+			}
+
+			for ver := n; ver < rec.Ver; ver++ {
+				v, bal, err := c.choose(ctx, rec.Key, ver, nil)
+				if err != nil && err != InstanceCompacted {
+					return
+				}
+				if err == nil && v != nil {
+					pending[rec.Key] = append(pending[rec.Key], &Record{Key: rec.Key, Ver: ver, Val: v, Bal: bal})
+				}
+			}
+			pending[rec.Key] = append(pending[rec.Key], rec)
+			next[rec.Key] = rec.Ver + 1
+
+			if err := c.flush(ctx, pending, rec.Key, out); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// flush sends the pending records of a key to `out`, up to the first intent of
+// a transaction not yet decided. Intents of aborted transactions are dropped.
+func (c *KVClient) flush(ctx context.Context, pending map[string][]*Record, key string, out chan<- *Record) error {
+
+	records := pending[key]
+	defer func() {
+		if len(records) == 0 {
+			delete(pending, key)
+		} else {
+			pending[key] = records
+		}
+	}()
+
+	for len(records) > 0 {
+		r := records[0]
+		if r.Val.TxnId != "" {
+			decided, committed, err := c.txnDecided(ctx, r.Val.TxnId)
+			if err != nil {
+				return err
+			}
+			if !decided {
+				return nil
+			}
+			if !committed {
+				records = records[1:]
+				continue
+			}
+		}
+		select {
+		case out <- r:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		records = records[1:]
+	}
+	return nil
+}
+
+// prefixEnd returns the smallest key greater than all keys with a prefix, or ""
+// if there is no such key.
+func prefixEnd(prefix string) string {
+	b := []byte(prefix)
+	for i := len(b) - 1; i >= 0; i-- {
+		if b[i] < 0xff {
+			b[i]++
+			return string(b[:i+1])
+		}
+	}
+	return ""
+}
@@ -0,0 +1,179 @@
+package paxoskv
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+func TestKVClient_Watch(t *testing.T) {
+
+	ta := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+
+	servers, err := ServeAcceptors(acceptorIds)
+	ta.Nil(err)
+	defer func() {
+		for _, s := range servers {
+			s.Stop()
+		}
+	}()
+
+	c := &KVClient{AcceptorIds: acceptorIds, ProposerId: 2}
+
+	_, err = c.Set("conf/a", &Value{Vi64: 1})
+	ta.Nil(err)
+	_, err = c.Set("other", &Value{Vi64: 1})
+	ta.Nil(err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch, err := c.Watch(ctx, "conf/", true, 0)
+	ta.Nil(err)
+
+	recv := func() *Record {
+		select {
+		case rec := <-ch:
+			return rec
+		case <-time.After(5 * time.Second):
+			ta.Fail("timeout waiting for a record")
+			return nil
+		}
+	}
+
+	rec := recv()
+	ta.Equal("conf/a", rec.Key, "a version written before Watch")
+	ta.Equal(int64(0), rec.Ver)
+	ta.Equal(int64(1), rec.Val.Vi64)
+	ta.NotNil(rec.Bal)
+
+	_, err = c.Set("conf/a", &Value{Vi64: 2})
+	ta.Nil(err)
+	rec = recv()
+	ta.Equal("conf/a", rec.Key)
+	ta.Equal(int64(1), rec.Ver)
+	ta.Equal(int64(2), rec.Val.Vi64)
+
+	err = c.Txn(
+		&TxnOp{Key: "conf/b", Val: &Value{Vi64: 3}},
+		&TxnOp{Key: "other", Val: &Value{Vi64: 3}},
+	)
+	ta.Nil(err)
+	rec = recv()
+	ta.Equal("conf/b", rec.Key, "a committed intent")
+	ta.Equal(int64(0), rec.Ver)
+	ta.Equal(int64(3), rec.Val.Vi64)
+	rec = recv()
+	ta.Equal("conf/b", rec.Key, "the resolved intent")
+	ta.Equal(int64(1), rec.Ver)
+	ta.Equal(int64(3), rec.Val.Vi64)
+
+	_, err = c.Delete("conf/a")
+	ta.Nil(err)
+	rec = recv()
+	ta.Equal("conf/a", rec.Key)
+	ta.Equal(int64(2), rec.Ver)
+	ta.True(rec.Val.Deleted)
+
+	cancel()
+	for range ch {
+	}
+}
+
+func TestKVClient_Watch_fillMissed(t *testing.T) {
+
+	ta := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+
+	servers, err := ServeAcceptors(acceptorIds)
+	ta.Nil(err)
+	defer func() {
+		for _, s := range servers {
+			s.Stop()
+		}
+	}()
+
+	c := &KVClient{AcceptorIds: acceptorIds, ProposerId: 2}
+
+	// foo₀ is chosen but not committed to any Acceptor.
+
+	p, err := c.newProposer("foo", 0)
+	ta.Nil(err)
+	p.Val = &Value{Vi64: 5}
+	_, err = p.Phase2(acceptorIds, 2)
+	ta.Nil(err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch, err := c.Watch(ctx, "foo", false, 0)
+	ta.Nil(err)
+
+	_, err = c.Set("foo", &Value{Vi64: 6})
+	ta.Nil(err)
+
+	for _, want := range []int64{5, 6} {
+		select {
+		case rec := <-ch:
+			ta.Equal(want, rec.Val.Vi64)
+		case <-time.After(5 * time.Second):
+			ta.Fail("timeout waiting for a record")
+		}
+	}
+}
+
+func TestKVClient_Watch_pendingTxn(t *testing.T) {
+
+	ta := require.New(t)
+
+	acceptorIds := []int64{0, 1, 2}
+
+	servers, err := ServeAcceptors(acceptorIds)
+	ta.Nil(err)
+	defer func() {
+		for _, s := range servers {
+			s.Stop()
+		}
+	}()
+
+	c := &KVClient{AcceptorIds: acceptorIds, ProposerId: 2}
+	bg := context.Background()
+
+	ctx, cancel := context.WithCancel(bg)
+	defer cancel()
+
+	ch, err := c.Watch(ctx, "foo", false, 0)
+	ta.Nil(err)
+
+	// an intent of a transaction that takes long to decide.
+
+	_, err = c.setFrom(bg, "foo", 0, &Value{Vi64: 5, TxnId: "slow"})
+	ta.Nil(err)
+
+	select {
+	case rec := <-ch:
+		ta.Fail("undecided intent delivered", "%v", rec)
+	case <-time.After(2 * time.Second):
+	}
+
+	decided, _, err := c.txnDecided(bg, "slow")
+	ta.Nil(err)
+	ta.False(decided, "Watch does not abort the transaction")
+
+	v, err := c.runPaxos(bg, txnKeyPrefix+"slow", 0, &Value{Vi64: txnCommitted})
+	ta.Nil(err)
+	ta.Equal(int64(txnCommitted), v.Vi64)
+
+	select {
+	case rec := <-ch:
+		ta.Equal(int64(0), rec.Ver, "delivered once committed")
+		ta.Equal(int64(5), rec.Val.Vi64)
+	case <-time.After(5 * time.Second):
+		ta.Fail("timeout waiting for a record")
+	}
+}